@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"regexp"
 	"runtime"
 	"strings"
 
+	"github.com/leejones/kubectl-nearby/pkg/distance"
+	"github.com/leejones/kubectl-nearby/pkg/drain"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/events"
 	"github.com/leejones/kubectl-nearby/pkg/nodes"
+	"github.com/leejones/kubectl-nearby/pkg/outage"
+	"github.com/leejones/kubectl-nearby/pkg/pods"
+	"github.com/leejones/kubectl-nearby/pkg/restarts"
+	"github.com/leejones/kubectl-nearby/pkg/spread"
 
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 )
@@ -22,33 +32,62 @@ func main() {
 	if len(os.Args) == 1 {
 		fmt.Fprintf(os.Stderr, "ERROR: A command is required.\n")
 		printGeneralUsage()
-		os.Exit(1)
+		os.Exit(errs.ExitUsage)
 	}
 
+	// Stop any requests to the cluster on Ctrl-C.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	subcommand := os.Args[1]
 	switch subcommand {
 	case "nodes", "node", "no":
 		nodesCLI := nodes.NodesCLI{}
-		err := nodesCLI.Execute(os.Args[2:], os.Stdout)
+		err := nodesCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
 		if err != nil {
-			fmt.Printf("ERROR: %s\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
 	case "pods", "pod", "po":
-		podsCLI, err := newPodsCLI(os.Args[2:])
+		podsCLI := pods.PodsCLI{}
+		err := podsCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			exitWithError(err)
+		}
+	case "distance":
+		distanceCLI := distance.DistanceCLI{}
+		err := distanceCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			exitWithError(err)
+		}
+	case "drain-impact":
+		drainCLI := drain.DrainCLI{}
+		err := drainCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			exitWithError(err)
+		}
+	case "restarts":
+		restartsCLI := restarts.RestartsCLI{}
+		err := restartsCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
 		if err != nil {
-			helpRequestedError := &helpRequestedError{}
-			if err.Error() == helpRequestedError.Error() {
-				podsCLI.printUsage()
-				os.Exit(0)
-			}
-			fmt.Printf("ERROR: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
 		}
-		err = podsCLI.execute()
+	case "events":
+		eventsCLI := events.EventsCLI{}
+		err := eventsCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
 		if err != nil {
-			fmt.Printf("ERROR: %v\n", err)
-			os.Exit(1)
+			exitWithError(err)
+		}
+	case "spread":
+		spreadCLI := spread.SpreadCLI{}
+		err := spreadCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			exitWithError(err)
+		}
+	case "simulate-outage":
+		outageCLI := outage.OutageCLI{}
+		err := outageCLI.ExecuteContext(ctx, os.Args[2:], os.Stdout)
+		if err != nil {
+			exitWithError(err)
 		}
 	case "--version", "--v":
 		printVersion()
@@ -58,10 +97,18 @@ func main() {
 			printGeneralUsage()
 		} else {
 			fmt.Fprintf(os.Stderr, "ERROR: Invalid command or options: %v\n", strings.Join(os.Args[1:], " "))
+			os.Exit(errs.ExitUsage)
 		}
 	}
 }
 
+// exitWithError prints the error and exits with the error's exit code (see
+// package errs).
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "ERROR: %v\n", strings.TrimSpace(err.Error()))
+	os.Exit(errs.ExitCode(err))
+}
+
 func helpRequested(args [](string)) bool {
 	helpMatcher := regexp.MustCompile(`(--help|-h)`)
 	for _, arg := range args {
@@ -76,14 +123,20 @@ func printGeneralUsage() {
 	generalUsage := `kubectl-nearby finds nearby pods or nodes.
 
 Commands:
-  nodes NODE     List nodes in the same zone as NODE.
-  pods POD       List pods on the same node as POD.
+  distance POD_A POD_B  Show the closest topology level shared by two pods.
+  drain-impact NODE     Show what draining NODE would evict and which pods would block it.
+  events POD            List the events of the node of POD and of every pod on it.
+  nodes NODE            List nodes in the same zone as NODE (or as the node of --pod POD).
+  pods POD              List pods on the same node as POD (or on --node NODE).
+  restarts POD          Show a timeline of restarts of the pods on the same node as POD.
+  simulate-outage       Show what would be lost if a zone (--zone) or node (--node) went down.
+  spread TYPE/NAME      Show how a workload's pods are spread across nodes, zones and regions.
 
 Use "kubectl-nearby COMMAND --help" for more information about a specific command.
 
 Global options:
 
-  --version, -v  Display the version and build information.
+  --version, -v         Display the version and build information.
 `
 	fmt.Fprint(os.Stderr, generalUsage)
 	if !flag.Parsed() {
@@ -0,0 +1,32 @@
+// Package cli holds the flag helpers shared by the kubectl-nearby commands.
+package cli
+
+import (
+	"flag"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// StringsFlag is a flag.Value that collects the values of a repeated flag.
+type StringsFlag []string
+
+func (s *StringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *StringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Usage writes the flag set's usage to the writer.
+//
+// By default, the flag package shows usage on CLI errors. This is a bit noisy
+// and makes the error less obvious, so commands discard the flag set's output
+// and only show usage in specific cases (e.g. --help).
+func Usage(flags *flag.FlagSet, writer io.Writer) {
+	flags.SetOutput(writer)
+	flags.Usage()
+	flags.SetOutput(ioutil.Discard)
+}
@@ -0,0 +1,43 @@
+package cli_test
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+)
+
+func TestStringsFlag(t *testing.T) {
+	var values cli.StringsFlag
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.Var(&values, "key", "A repeated flag")
+	err := f.Parse([]string{"--key", "a", "--key", "b"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := (cli.StringsFlag{"a", "b"}); !reflect.DeepEqual(expected, values) {
+		t.Errorf("Expected values: %v, got: %v", expected, values)
+	}
+	if values.String() != "a,b" {
+		t.Errorf("Expected string: a,b, got: %v", values.String())
+	}
+}
+
+func TestUsage(t *testing.T) {
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.Usage = func() {
+		f.Output().Write([]byte("USAGE\n"))
+	}
+	f.SetOutput(ioutil.Discard)
+	writer := bytes.NewBufferString("")
+	cli.Usage(f, writer)
+	if writer.String() != "USAGE\n" {
+		t.Errorf("Expected output: USAGE, got: %v", writer.String())
+	}
+	if f.Output() != ioutil.Discard {
+		t.Errorf("Expected the flag set's output to be discarded again")
+	}
+}
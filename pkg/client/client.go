@@ -0,0 +1,327 @@
+// Package client builds Kubernetes clients from the connection flags shared
+// by every kubectl-nearby command.
+package client
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// Where the client config is loaded from.
+const (
+	// SourceAuto uses the kubeconfig if one is found, otherwise the
+	// in-cluster config.
+	SourceAuto       = "auto"
+	SourceKubeconfig = "kubeconfig"
+	// SourceInCluster uses the pod's service account (e.g. when running as a
+	// Job).
+	SourceInCluster = "in-cluster"
+)
+
+// serviceAccountDirectory is where Kubernetes mounts a pod's service account
+// credentials.
+const serviceAccountDirectory = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Flags holds the kubectl connection flags.
+type Flags struct {
+	ConfigSource          string
+	Kubeconfig            string
+	Context               string
+	Cluster               string
+	User                  string
+	Namespace             string
+	As                    string
+	AsGroups              []string
+	Server                string
+	Token                 string
+	InsecureSkipTLSVerify bool
+	RequestTimeout        string
+	// Contexts is a comma separated list of kubeconfig contexts to run
+	// against.
+	Contexts    string
+	AllContexts bool
+}
+
+// AddFlags defines the connection flags on the flag set. They have the same
+// names and meaning as kubectl's.
+func (f *Flags) AddFlags(flags *flag.FlagSet) {
+	flags.StringVar(&f.ConfigSource, "config-source", SourceAuto, "Where to load the cluster configuration from. One of: auto (the kubeconfig if found, otherwise the in-cluster service account), kubeconfig, in-cluster")
+	flags.StringVar(&f.Kubeconfig, "kubeconfig", "", fmt.Sprintf("(optional) An absolute path to the kubeconfig file (defaults to the value of KUBECONFIG from the ENV if set or the file %s if present)", clientcmd.RecommendedHomeFile))
+	flags.StringVar(&f.Context, "context", "", "The name of the kubeconfig context to use")
+	flags.StringVar(&f.Cluster, "cluster", "", "The name of the kubeconfig cluster to use")
+	flags.StringVar(&f.User, "user", "", "The name of the kubeconfig user to use")
+	flags.StringVar(&f.Namespace, "namespace", "", "Namespace of the target (defaults to namespace set in kubeconfig if set, otherwise 'default')")
+	flags.StringVar(&f.As, "as", "", "Username to impersonate for the operation")
+	flags.Var((*cli.StringsFlag)(&f.AsGroups), "as-group", "Group to impersonate for the operation (can be repeated)")
+	flags.StringVar(&f.Server, "server", "", "The address and port of the Kubernetes API server")
+	flags.StringVar(&f.Token, "token", "", "Bearer token for authentication to the API server")
+	flags.BoolVar(&f.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "If true, the server's certificate will not be checked for validity")
+	flags.StringVar(&f.RequestTimeout, "request-timeout", "0", "The length of time to wait before giving up on a single server request (e.g. 1s, 2m, 3h). Zero means don't timeout requests")
+}
+
+// AddContextsFlags defines the flags selecting several kubeconfig contexts to
+// run against.
+func (f *Flags) AddContextsFlags(flags *flag.FlagSet) {
+	flags.StringVar(&f.Contexts, "contexts", "", "A comma separated list of kubeconfig contexts to query concurrently (e.g. prod-east,prod-west)")
+	flags.BoolVar(&f.AllContexts, "all-contexts", false, "Query every kubeconfig context concurrently")
+}
+
+// ContextNames returns the kubeconfig contexts selected with --contexts or
+// --all-contexts, or nil if neither is given.
+func (f *Flags) ContextNames() ([]string, error) {
+	if f.Contexts == "" && !f.AllContexts {
+		return nil, nil
+	}
+	switch {
+	case f.Contexts != "" && f.AllContexts:
+		return nil, errs.ErrUsage{Err: fmt.Errorf("--contexts and --all-contexts cannot be given together")}
+	case f.Context != "":
+		return nil, errs.ErrUsage{Err: fmt.Errorf("--context cannot be given with --contexts or --all-contexts")}
+	case f.ConfigSource == SourceInCluster:
+		return nil, errs.ErrUsage{Err: fmt.Errorf("--contexts and --all-contexts require a kubeconfig")}
+	}
+
+	rawConfig, err := f.ClientConfig().RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load kubeconfig: %v", err)
+	}
+	names := []string{}
+	if f.AllContexts {
+		for name := range rawConfig.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no contexts found in kubeconfig")
+		}
+		return names, nil
+	}
+	for _, name := range strings.Split(f.Contexts, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := rawConfig.Contexts[name]; !ok {
+			return nil, errs.ErrNotFound{Kind: "context", Name: name}
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ForContext returns a copy of the flags using the given kubeconfig context.
+func (f *Flags) ForContext(name string) *Flags {
+	flags := *f
+	flags.Context = name
+	flags.Contexts = ""
+	flags.AllContexts = false
+	return &flags
+}
+
+// ClientConfig returns the client config loaded from the kubeconfig file (or
+// the standard locations if none is given) with the flags applied.
+func (f *Flags) ClientConfig() clientcmd.ClientConfig {
+	var loadingRules *clientcmd.ClientConfigLoadingRules
+	if f.Kubeconfig == "" {
+		// Look in the standard places
+		loadingRules = clientcmd.NewDefaultClientConfigLoadingRules()
+	} else {
+		// Load from given kubeconfig
+		loadingRules = &clientcmd.ClientConfigLoadingRules{
+			Precedence: []string{f.Kubeconfig},
+		}
+	}
+
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: f.Context,
+		Context: clientcmdapi.Context{
+			Cluster:   f.Cluster,
+			AuthInfo:  f.User,
+			Namespace: f.Namespace,
+		},
+		AuthInfo: clientcmdapi.AuthInfo{
+			Impersonate:       f.As,
+			ImpersonateGroups: f.AsGroups,
+			Token:             f.Token,
+		},
+		ClusterInfo: clientcmdapi.Cluster{
+			Server:                f.Server,
+			InsecureSkipTLSVerify: f.InsecureSkipTLSVerify,
+		},
+		Timeout: f.RequestTimeout,
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+}
+
+// CurrentNamespace returns the namespace given with --namespace, otherwise
+// the namespace of the kubeconfig context (or the pod's namespace in-cluster)
+// or "default".
+func (f *Flags) CurrentNamespace() (string, error) {
+	source, err := f.source()
+	if err != nil {
+		return "", err
+	}
+	if source == SourceInCluster {
+		return f.inClusterNamespace(), nil
+	}
+	namespace, _, err := f.ClientConfig().Namespace()
+	if err != nil {
+		return "", fmt.Errorf("unable to get namespace from kubeconfig: %v", err)
+	}
+	return namespace, nil
+}
+
+// RESTConfig returns the REST config for the flags.
+func (f *Flags) RESTConfig() (*rest.Config, error) {
+	source, err := f.source()
+	if err != nil {
+		return nil, err
+	}
+	if source == SourceInCluster {
+		return f.inClusterConfig()
+	}
+
+	if f.Kubeconfig != "" {
+		_, err := os.Stat(f.Kubeconfig)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file: %v", err)
+		}
+	}
+	config, err := f.ClientConfig().ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not initialize Kubernetes client config: %v", err)
+	}
+	return config, nil
+}
+
+// source returns where the client config is loaded from, resolving
+// SourceAuto.
+func (f *Flags) source() (string, error) {
+	switch f.ConfigSource {
+	case SourceKubeconfig, SourceInCluster:
+		return f.ConfigSource, nil
+	case "", SourceAuto:
+	default:
+		return "", errs.ErrUsage{Err: fmt.Errorf("invalid config source: %q (must be one of: %v, %v, %v)", f.ConfigSource, SourceAuto, SourceKubeconfig, SourceInCluster)}
+	}
+
+	// Flags that only make sense with a kubeconfig select it.
+	if f.Kubeconfig != "" || f.Context != "" || f.Cluster != "" || f.User != "" || f.Server != "" {
+		return SourceKubeconfig, nil
+	}
+	rawConfig, err := f.ClientConfig().RawConfig()
+	if err == nil && !clientcmdapi.IsConfigEmpty(&rawConfig) {
+		return SourceKubeconfig, nil
+	}
+	if inCluster() {
+		return SourceInCluster, nil
+	}
+	return "", fmt.Errorf("no cluster configuration found: set KUBECONFIG, pass --kubeconfig or --server, or run inside a cluster with a service account")
+}
+
+// inCluster returns true if running in a pod with a service account.
+func inCluster() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" || os.Getenv("KUBERNETES_SERVICE_PORT") == "" {
+		return false
+	}
+	_, err := os.Stat(path.Join(serviceAccountDirectory, "token"))
+	return err == nil
+}
+
+// inClusterConfig returns the pod's service account config with the flags
+// applied.
+func (f *Flags) inClusterConfig() (*rest.Config, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not load in-cluster config (is kubectl-nearby running in a pod with a service account?): %v", err)
+	}
+	if f.Server != "" {
+		config.Host = f.Server
+	}
+	if f.Token != "" {
+		config.BearerToken = f.Token
+		config.BearerTokenFile = ""
+	}
+	if f.InsecureSkipTLSVerify {
+		config.Insecure = true
+		config.TLSClientConfig.CAFile = ""
+		config.TLSClientConfig.CAData = nil
+	}
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: f.As,
+		Groups:   f.AsGroups,
+	}
+	if f.RequestTimeout != "" {
+		timeout := f.RequestTimeout
+		// Like kubectl, a number without a unit is in seconds.
+		if _, err := strconv.Atoi(timeout); err == nil {
+			timeout += "s"
+		}
+		config.Timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, errs.ErrUsage{Err: fmt.Errorf("invalid request timeout: %v", err)}
+		}
+	}
+	return config, nil
+}
+
+// inClusterNamespace returns the namespace given with --namespace, otherwise
+// the pod's namespace or "default".
+func (f *Flags) inClusterNamespace() string {
+	if f.Namespace != "" {
+		return f.Namespace
+	}
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	data, err := os.ReadFile(path.Join(serviceAccountDirectory, "namespace"))
+	if err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return metav1.NamespaceDefault
+}
+
+// NewClient returns a Kubernetes client for the flags.
+func (f *Flags) NewClient() (*kubernetes.Clientset, error) {
+	config, err := f.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create clientset from config: %v", err)
+	}
+	return clientset, nil
+}
+
+// NewMetricsClient returns a client for the metrics.k8s.io API (served by
+// metrics-server) for the flags.
+func (f *Flags) NewMetricsClient() (*metricsclientset.Clientset, error) {
+	config, err := f.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := metricsclientset.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create metrics clientset from config: %v", err)
+	}
+	return clientset, nil
+}
@@ -0,0 +1,251 @@
+package client_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+func TestFlags(t *testing.T) {
+	kubeconfig := path.Join(testdataDirectory(t), "test-kube-config")
+
+	t.Run("without flags, uses the kubeconfig's current context", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig)
+		namespace, err := connection.CurrentNamespace()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if namespace != "testing-namespace" {
+			t.Errorf("Expected namespace: testing-namespace, got: %v", namespace)
+		}
+		config, err := connection.RESTConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Host != "http://127.0.0.1" {
+			t.Errorf("Expected host: http://127.0.0.1, got: %v", config.Host)
+		}
+	})
+
+	t.Run("with --context, uses the context's cluster and namespace", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--context", "other-context")
+		namespace, err := connection.CurrentNamespace()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if namespace != "other-namespace" {
+			t.Errorf("Expected namespace: other-namespace, got: %v", namespace)
+		}
+		config, err := connection.RESTConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Host != "http://127.0.0.2" {
+			t.Errorf("Expected host: http://127.0.0.2, got: %v", config.Host)
+		}
+	})
+
+	t.Run("with --cluster and --namespace, overrides the context", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--cluster", "other-cluster", "--namespace", "my-namespace")
+		namespace, err := connection.CurrentNamespace()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if namespace != "my-namespace" {
+			t.Errorf("Expected namespace: my-namespace, got: %v", namespace)
+		}
+		config, err := connection.RESTConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Host != "http://127.0.0.2" {
+			t.Errorf("Expected host: http://127.0.0.2, got: %v", config.Host)
+		}
+	})
+
+	t.Run("with server, credential and impersonation flags", func(t *testing.T) {
+		connection := parse(t,
+			"--kubeconfig", kubeconfig,
+			"--server", "https://10.0.0.1:6443",
+			"--token", "secret",
+			"--insecure-skip-tls-verify",
+			"--as", "jane",
+			"--as-group", "devs",
+			"--as-group", "ops",
+			"--request-timeout", "5s",
+		)
+		config, err := connection.RESTConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Host != "https://10.0.0.1:6443" {
+			t.Errorf("Expected host: https://10.0.0.1:6443, got: %v", config.Host)
+		}
+		if config.BearerToken != "secret" {
+			t.Errorf("Expected bearer token: secret, got: %v", config.BearerToken)
+		}
+		if !config.Insecure {
+			t.Errorf("Expected insecure: true, got: %v", config.Insecure)
+		}
+		if config.Impersonate.UserName != "jane" || !reflect.DeepEqual(config.Impersonate.Groups, []string{"devs", "ops"}) {
+			t.Errorf("Expected impersonation of jane in [devs ops], got: %+v", config.Impersonate)
+		}
+		if config.Timeout != 5*time.Second {
+			t.Errorf("Expected timeout: 5s, got: %v", config.Timeout)
+		}
+	})
+
+	t.Run("with an invalid --request-timeout, returns an error", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--request-timeout", "soon")
+		_, err := connection.RESTConfig()
+		if err == nil {
+			t.Errorf("Expected an error, got: nil")
+		}
+	})
+
+	t.Run("with a missing kubeconfig, returns an error", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", path.Join(testdataDirectory(t), "missing"))
+		_, err := connection.NewClient()
+		if err == nil {
+			t.Errorf("Expected an error, got: nil")
+		}
+	})
+}
+
+func TestContextNames(t *testing.T) {
+	kubeconfig := path.Join(testdataDirectory(t), "test-kube-config")
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{"without --contexts or --all-contexts", []string{}, nil},
+		{"with --contexts", []string{"--contexts", "other-context, test-context"}, []string{"other-context", "test-context"}},
+		{"with --all-contexts", []string{"--all-contexts"}, []string{"other-context", "test-context"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			connection := parse(t, append([]string{"--kubeconfig", kubeconfig}, testCase.args...)...)
+			got, err := connection.ContextNames()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(testCase.expected, got) {
+				t.Errorf("Expected contexts: %v, got: %v", testCase.expected, got)
+			}
+		})
+	}
+
+	t.Run("with an unknown context, returns a not found error", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--contexts", "missing")
+		_, err := connection.ContextNames()
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+
+	t.Run("with --contexts and --all-contexts, returns a usage error", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--contexts", "test-context", "--all-contexts")
+		_, err := connection.ContextNames()
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+
+	t.Run("ForContext uses the context's namespace", func(t *testing.T) {
+		connection := parse(t, "--kubeconfig", kubeconfig, "--all-contexts")
+		namespace, err := connection.ForContext("other-context").CurrentNamespace()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if namespace != "other-namespace" {
+			t.Errorf("Expected namespace: other-namespace, got: %v", namespace)
+		}
+	})
+}
+
+func TestConfigSource(t *testing.T) {
+	// Outside of a cluster with no kubeconfig.
+	t.Setenv("KUBECONFIG", path.Join(testdataDirectory(t), "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "")
+
+	t.Run("with neither a kubeconfig nor a service account, returns an error", func(t *testing.T) {
+		connection := parse(t)
+		_, err := connection.RESTConfig()
+		if err == nil || !strings.Contains(err.Error(), "no cluster configuration found") {
+			t.Errorf("Expected a no cluster configuration error, got: %v", err)
+		}
+	})
+
+	t.Run("with --config-source in-cluster outside a cluster, returns an error", func(t *testing.T) {
+		connection := parse(t, "--config-source", "in-cluster", "--kubeconfig", path.Join(testdataDirectory(t), "test-kube-config"))
+		_, err := connection.NewClient()
+		if err == nil || !strings.Contains(err.Error(), "in-cluster") {
+			t.Errorf("Expected an in-cluster config error, got: %v", err)
+		}
+	})
+
+	t.Run("with --config-source in-cluster, uses the pod's namespace", func(t *testing.T) {
+		t.Setenv("POD_NAMESPACE", "pod-namespace")
+		connection := parse(t, "--config-source", "in-cluster")
+		namespace, err := connection.CurrentNamespace()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if namespace != "pod-namespace" {
+			t.Errorf("Expected namespace: pod-namespace, got: %v", namespace)
+		}
+	})
+
+	t.Run("with --config-source kubeconfig, uses the kubeconfig", func(t *testing.T) {
+		connection := parse(t, "--config-source", "kubeconfig", "--kubeconfig", path.Join(testdataDirectory(t), "test-kube-config"))
+		config, err := connection.RESTConfig()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if config.Host != "http://127.0.0.1" {
+			t.Errorf("Expected host: http://127.0.0.1, got: %v", config.Host)
+		}
+	})
+
+	t.Run("with an invalid --config-source, returns a usage error", func(t *testing.T) {
+		connection := parse(t, "--config-source", "cloud")
+		_, err := connection.RESTConfig()
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func parse(t *testing.T, args ...string) *client.Flags {
+	t.Helper()
+	f := flag.NewFlagSet("test", flag.ContinueOnError)
+	f.SetOutput(ioutil.Discard)
+	connection := &client.Flags{}
+	connection.AddFlags(f)
+	connection.AddContextsFlags(f)
+	err := f.Parse(args)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing %v: %v", args, err)
+	}
+	return connection
+}
+
+func testdataDirectory(t *testing.T) string {
+	t.Helper()
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("working directory: %v", err)
+	}
+	return path.Join(workingDirectory, "../..", "testdata")
+}
@@ -0,0 +1,87 @@
+// Package clienttest provides the kubeconfig and the objects used to test
+// kubectl-nearby commands against a fake clientset.
+package clienttest
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Namespace is the namespace of the context in test-default-kube-config.
+const Namespace = "testing-cluster-default"
+
+// Testdata returns the path of the named file in the repository's testdata
+// directory.
+func Testdata(name string) string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "testdata", name)
+}
+
+// SetupKubeconfig points KUBECONFIG at test-default-kube-config so the
+// results don't depend on the user's kubeconfig (e.g. its namespace) or on
+// having one at all (e.g. in CI).
+func SetupKubeconfig(t testing.TB) {
+	t.Helper()
+	t.Setenv("KUBECONFIG", Testdata("test-default-kube-config"))
+}
+
+// An Executor is a command line interface, e.g. a *pods.PodsCLI.
+type Executor interface {
+	Execute(args []string, writer io.Writer) error
+}
+
+// Execute runs the command with the arguments and returns its output.
+func Execute(cli Executor, args []string) (string, error) {
+	writer := bytes.NewBufferString("")
+	err := cli.Execute(args, writer)
+	return writer.String(), err
+}
+
+// Node returns a node with the given labels.
+func Node(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+// TopologyLabels returns the zone and region labels of a node, leaving out
+// the empty ones.
+func TopologyLabels(zone string, region string) map[string]string {
+	labels := map[string]string{}
+	if zone != "" {
+		labels[v1.LabelTopologyZone] = zone
+	}
+	if region != "" {
+		labels[v1.LabelTopologyRegion] = region
+	}
+	return labels
+}
+
+// Pod returns a running and ready pod in Namespace with the given labels. An
+// empty nodeName returns a pending, unscheduled pod.
+func Pod(name string, nodeName string, labels map[string]string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace, Labels: labels},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+		Status:     v1.PodStatus{Phase: v1.PodPending},
+	}
+	if nodeName != "" {
+		pod.Status.Phase = v1.PodRunning
+		pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	}
+	return pod
+}
+
+// OwnedPod returns a pod like Pod, labeled app=owner and controlled by the
+// owner of the given kind. The owner's UID is its name.
+func OwnedPod(name string, nodeName string, kind string, owner string) *v1.Pod {
+	controller := true
+	pod := Pod(name, nodeName, map[string]string{"app": owner})
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, UID: types.UID(owner), Controller: &controller}}
+	return pod
+}
@@ -0,0 +1,161 @@
+// Package distance provides a CLI to compare the topology of pods.
+package distance
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+// A DistanceCLI is used to create a command line interface for comparing the
+// topology of pods.
+type DistanceCLI struct {
+	Client kubernetes.Interface
+}
+
+// ErrPodNamesRequired is returned when neither two pod names nor a selector
+// are given.
+type ErrPodNamesRequired struct{}
+
+func (err ErrPodNamesRequired) Error() string {
+	return "two pod names or a selector are required"
+}
+
+func (err ErrPodNamesRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// Execute writes the closest topology level shared by two pods, or a matrix
+// of them for the pods matching a selector, to the given io.Writer and
+// returns an error.
+func (d *DistanceCLI) Execute(args []string, writer io.Writer) error {
+	return d.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (d *DistanceCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	podNames := []string{}
+	remainingArgs := args
+	for len(remainingArgs) > 0 && !strings.HasPrefix(remainingArgs[0], "-") {
+		podNames = append(podNames, remainingArgs[0])
+		remainingArgs = remainingArgs[1:]
+	}
+
+	f := flag.NewFlagSet("kubectl nearby distance", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Show the closest topology level (node, zone or region) shared by pods.\n\nUSAGE\n\n  %s distance POD_A POD_B [OPTIONS]\n  %s distance -l SELECTOR [OPTIONS]\n\nOPTIONS\n\n", os.Args[0], os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+	var selector string
+	f.StringVar(&selector, "selector", "", "Label selector for the pods (e.g. app=checkout), used instead of pod names to show the distance between every pair of matching pods")
+	f.StringVar(&selector, "l", "", "Shorthand for --selector")
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	if selector != "" && len(podNames) > 0 {
+		return errs.ErrUsage{Err: fmt.Errorf("pod names and a selector cannot be given together")}
+	} else if selector == "" && len(podNames) != 2 {
+		return ErrPodNamesRequired{}
+	}
+
+	namespace, err := connection.CurrentNamespace()
+	if err != nil {
+		return err
+	}
+	if d.Client == nil {
+		d.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	var pods []v1.Pod
+	if selector != "" {
+		pods, err = nearby.SelectorPods(ctx, d.Client, namespace, selector)
+		if err != nil {
+			return err
+		}
+		sort.Slice(pods, func(i, j int) bool {
+			return pods[i].Name < pods[j].Name
+		})
+	} else {
+		for _, podName := range podNames {
+			pod, err := d.Client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+			if err != nil {
+				return errs.FromAPI(err, "pod", namespace, podName)
+			}
+			pods = append(pods, *pod)
+		}
+	}
+
+	locations, err := nearby.Locate(ctx, d.Client, pods)
+	if err != nil {
+		return err
+	}
+	if selector != "" {
+		return printMatrix(locations, writer)
+	}
+	return printPair(locations[0], locations[1], writer)
+}
+
+// printPair prints where each pod runs and the closest level they share.
+func printPair(a nearby.Location, b nearby.Location, writer io.Writer) error {
+	rows := [][]string{{"NAME", "NODE", "ZONE", "REGION"}}
+	for _, location := range []nearby.Location{a, b} {
+		rows = append(rows, []string{location.Pod.Name, output.NoneIfEmpty(location.Node), output.NoneIfEmpty(location.Zone), output.NoneIfEmpty(location.Region)})
+	}
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	_, err = fmt.Fprintf(writer, "%v\n\nClosest shared level: %v\n", formatted, nearby.Distance(a, b))
+	return err
+}
+
+// printMatrix prints the closest level shared by every pair of pods.
+func printMatrix(locations []nearby.Location, writer io.Writer) error {
+	header := []string{"NAME"}
+	for _, location := range locations {
+		header = append(header, location.Pod.Name)
+	}
+	rows := [][]string{header}
+	for _, a := range locations {
+		row := []string{a.Pod.Name}
+		for _, b := range locations {
+			row = append(row, nearby.Distance(a, b))
+		}
+		rows = append(rows, row)
+	}
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	_, err = fmt.Fprintln(writer, formatted)
+	return err
+}
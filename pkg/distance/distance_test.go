@@ -0,0 +1,112 @@
+package distance_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/distance"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"with two pods in the same zone",
+			[]string{"nginx-abc123", "web-1"},
+			`NAME          NODE      ZONE        REGION
+nginx-abc123  node-a-1  us-east4-a  us-east4
+web-1         node-a-2  us-east4-a  us-east4
+
+Closest shared level: zone
+`,
+		},
+		{
+			"with an unscheduled pod",
+			[]string{"nginx-abc123", "pending-1"},
+			`NAME          NODE      ZONE        REGION
+nginx-abc123  node-a-1  us-east4-a  us-east4
+pending-1     <none>    <none>      <none>
+
+Closest shared level: unknown
+`,
+		},
+		{
+			"with a selector, prints a matrix",
+			[]string{"-l", "app=web"},
+			`NAME   web-1   web-2   web-3
+web-1  node    region  none
+web-2  region  node    none
+web-3  none    none    node
+`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			distanceCLI := distance.DistanceCLI{
+				Client: testClient(),
+			}
+			err := distanceCLI.Execute(testCase.args, writer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testCase.expected != writer.String() {
+				t.Errorf("Expected output:\n%v\ngot:\n%v", testCase.expected, writer.String())
+			}
+		})
+	}
+
+	t.Run("with one pod name, returns an error", func(t *testing.T) {
+		distanceCLI := distance.DistanceCLI{
+			Client: testClient(),
+		}
+		err := distanceCLI.Execute([]string{"nginx-abc123"}, bytes.NewBufferString(""))
+		var required distance.ErrPodNamesRequired
+		if !errors.As(err, &required) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", required, err, err)
+		}
+	})
+
+	t.Run("with an unknown pod, returns a not found error", func(t *testing.T) {
+		distanceCLI := distance.DistanceCLI{
+			Client: testClient(),
+		}
+		err := distanceCLI.Execute([]string{"nginx-abc123", "missing"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+}
+
+// testClient returns a fake client with nodes in two regions:
+//
+//	node-a-1 (us-east4-a): nginx-abc123
+//	node-a-2 (us-east4-a): web-1
+//	node-b-1 (us-east4-b): web-2
+//	node-c-1 (us-west1-a): web-3
+//
+// and an unscheduled pod, pending-1.
+func testClient() kubernetes.Interface {
+	return testclient.NewSimpleClientset(
+		clienttest.Node("node-a-1", clienttest.TopologyLabels("us-east4-a", "us-east4")),
+		clienttest.Node("node-a-2", clienttest.TopologyLabels("us-east4-a", "us-east4")),
+		clienttest.Node("node-b-1", clienttest.TopologyLabels("us-east4-b", "us-east4")),
+		clienttest.Node("node-c-1", clienttest.TopologyLabels("us-west1-a", "us-west1")),
+		clienttest.Pod("nginx-abc123", "node-a-1", nil),
+		clienttest.Pod("web-1", "node-a-2", map[string]string{"app": "web"}),
+		clienttest.Pod("web-2", "node-b-1", map[string]string{"app": "web"}),
+		clienttest.Pod("web-3", "node-c-1", map[string]string{"app": "web"}),
+		clienttest.Pod("pending-1", "", nil),
+	)
+}
@@ -0,0 +1,182 @@
+// Package drain provides a CLI to report the impact of draining a node.
+package drain
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+// A DrainCLI is used to create a command line interface for reporting the
+// impact of draining a node.
+type DrainCLI struct {
+	Client kubernetes.Interface
+}
+
+// ErrNodeNameRequired is returned when no node name is given.
+type ErrNodeNameRequired struct{}
+
+func (err ErrNodeNameRequired) Error() string {
+	return "a node name is required"
+}
+
+func (err ErrNodeNameRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// Execute writes the pods on the node, which of them would block a drain and
+// the PodDisruptionBudgets involved to the given io.Writer and returns an
+// error.
+func (d *DrainCLI) Execute(args []string, writer io.Writer) error {
+	return d.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (d *DrainCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	var nodeName string
+	remainingArgs := args
+	if len(args) > 0 {
+		matched, err := regexp.MatchString("^-", args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing arguments")
+		}
+		if !matched {
+			nodeName = args[0]
+			remainingArgs = args[1:]
+		}
+	}
+
+	f := flag.NewFlagSet("kubectl nearby drain-impact", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Show what draining a node would evict and which pods would block it.\n\nUSAGE\n\n  %s drain-impact NODE [OPTIONS]\n\nOPTIONS\n\n", os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	if nodeName == "" {
+		return ErrNodeNameRequired{}
+	}
+
+	if d.Client == nil {
+		d.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := nearby.DrainImpact(ctx, d.Client, nodeName)
+	if err != nil {
+		return err
+	}
+
+	evicted, skipped, blocked := 0, 0, 0
+	rows := [][]string{{"NAMESPACE", "NAME", "WORKLOAD", "FLAGS", "EVICTION", "REASON"}}
+	for _, pod := range result.Pods {
+		flags := []string{}
+		if pod.Unmanaged {
+			flags = append(flags, "NO-CONTROLLER")
+		}
+		if pod.LocalStorage {
+			flags = append(flags, "EMPTYDIR")
+		}
+		if pod.DaemonSet() {
+			flags = append(flags, "DAEMONSET")
+		}
+		if pod.Mirror {
+			flags = append(flags, "MIRROR")
+		}
+		workload := "-"
+		if !pod.Unmanaged {
+			workload = strings.ToLower(pod.Workload.Kind) + "/" + pod.Workload.Name
+		}
+		eviction, reason := "OK", "-"
+		switch reasons := pod.Blocked(); {
+		case pod.DaemonSet():
+			eviction, reason = "SKIPPED", "DaemonSet pods are not evicted"
+			skipped++
+		case pod.Mirror:
+			eviction, reason = "SKIPPED", "mirror pods are not evicted"
+			skipped++
+		case pod.Finished():
+			eviction, reason = "SKIPPED", "pod has finished"
+			skipped++
+		case len(reasons) > 0:
+			eviction, reason = "BLOCKED", strings.Join(reasons, ", ")
+			blocked++
+		default:
+			evicted++
+		}
+		rows = append(rows, []string{
+			pod.Pod.Namespace,
+			pod.Pod.Name,
+			workload,
+			output.DashIfEmpty(strings.Join(flags, ",")),
+			eviction,
+			reason,
+		})
+	}
+
+	sections := []string{fmt.Sprintf("Draining node %v: %v pods can be evicted, %v blocked, %v skipped", nodeName, evicted, blocked, skipped)}
+	if len(result.Pods) > 0 {
+		formatted, err := output.Columns(rows)
+		if err != nil {
+			return fmt.Errorf("printing output: %v", err)
+		}
+		sections = append(sections, formatted)
+	}
+
+	if len(result.DisruptionBudgets) > 0 {
+		rows := [][]string{{"NAMESPACE", "NAME", "HEALTHY", "MIN-HEALTHY", "REMAINING", "STATUS"}}
+		for _, impact := range result.DisruptionBudgets {
+			status := "OK"
+			if impact.Violated() {
+				status = "VIOLATED"
+			}
+			rows = append(rows, []string{
+				impact.Namespace,
+				impact.Name,
+				strconv.Itoa(impact.Healthy),
+				strconv.Itoa(impact.DesiredHealthy),
+				strconv.Itoa(impact.Remaining),
+				status,
+			})
+		}
+		formatted, err := output.Columns(rows)
+		if err != nil {
+			return fmt.Errorf("printing output: %v", err)
+		}
+		sections = append(sections, "POD DISRUPTION BUDGETS\n"+formatted)
+	}
+
+	_, err = fmt.Fprintln(writer, strings.Join(sections, "\n\n"))
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return nil
+}
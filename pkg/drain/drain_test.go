@@ -0,0 +1,108 @@
+package drain_test
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/drain"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	t.Run("with a node, shows the pods that would block a drain", func(t *testing.T) {
+		expected := `Draining node node-a-1: 1 pods can be evicted, 3 blocked, 3 skipped
+
+NAMESPACE                NAME                 WORKLOAD           FLAGS          EVICTION  REASON
+testing-cluster-default  agent-x1             daemonset/agent    DAEMONSET      SKIPPED   DaemonSet pods are not evicted
+testing-cluster-default  backup-1             job/backup         -              SKIPPED   pod has finished
+testing-cluster-default  cache-1              statefulset/cache  EMPTYDIR       BLOCKED   emptyDir volume (needs --delete-emptydir-data)
+testing-cluster-default  debug                -                  NO-CONTROLLER  BLOCKED   no controller (needs --force)
+testing-cluster-default  kube-proxy-node-a-1  node/node-a-1      MIRROR         SKIPPED   mirror pods are not evicted
+testing-cluster-default  zk-0                 statefulset/zk     -              OK        -
+testing-cluster-default  zk-1                 statefulset/zk     -              BLOCKED   PodDisruptionBudget zk
+
+POD DISRUPTION BUDGETS
+NAMESPACE                NAME  HEALTHY  MIN-HEALTHY  REMAINING  STATUS
+testing-cluster-default  zk    3        2            1          VIOLATED
+`
+		got, err := execute([]string{"node-a-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with a node without pods, says so", func(t *testing.T) {
+		expected := "Draining node node-c-1: 0 pods can be evicted, 0 blocked, 0 skipped\n"
+		got, err := execute([]string{"node-c-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an unknown node, returns not found", func(t *testing.T) {
+		_, err := execute([]string{"node-z-1"})
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+
+	t.Run("with no node, returns a usage error", func(t *testing.T) {
+		_, err := execute([]string{})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func execute(args []string) (string, error) {
+	return clienttest.Execute(&drain.DrainCLI{Client: testClient()}, args)
+}
+
+// testClient returns a fake client where node-a-1 runs a DaemonSet pod, a
+// finished Job pod, a pod with an emptyDir volume, a pod without a
+// controller, a mirror pod and two of the three pods of the StatefulSet zk,
+// whose PodDisruptionBudget allows one to be down.
+func testClient() kubernetes.Interface {
+	minAvailable := intstr.FromInt32(2)
+	cache := clienttest.OwnedPod("cache-1", "node-a-1", "StatefulSet", "cache")
+	cache.Spec.Volumes = []v1.Volume{{Name: "scratch", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+	mirror := clienttest.OwnedPod("kube-proxy-node-a-1", "node-a-1", "Node", "node-a-1")
+	mirror.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "abc123"}
+	backup := clienttest.OwnedPod("backup-1", "node-a-1", "Job", "backup")
+	backup.Status.Phase = v1.PodSucceeded
+	return testclient.NewSimpleClientset(
+		clienttest.Node("node-a-1", nil),
+		clienttest.Node("node-b-1", nil),
+		clienttest.Node("node-c-1", nil),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "zk", Namespace: clienttest.Namespace},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "zk"}},
+			},
+		},
+		clienttest.OwnedPod("agent-x1", "node-a-1", "DaemonSet", "agent"),
+		backup,
+		cache,
+		mirror,
+		clienttest.Pod("debug", "node-a-1", nil),
+		clienttest.OwnedPod("zk-0", "node-a-1", "StatefulSet", "zk"),
+		clienttest.OwnedPod("zk-1", "node-a-1", "StatefulSet", "zk"),
+		clienttest.OwnedPod("zk-2", "node-b-1", "StatefulSet", "zk"),
+	)
+}
@@ -0,0 +1,262 @@
+// Package errs defines the errors returned by kubectl-nearby commands and the
+// process exit codes they map to.
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Process exit codes. Scripts can use these to tell failures apart (e.g. a
+// missing pod from an unreachable cluster).
+const (
+	ExitOK                   = 0
+	ExitError                = 1
+	ExitUsage                = 2
+	ExitNotFound             = 3
+	ExitForbidden            = 4
+	ExitUnscheduled          = 5
+	ExitTopologyLabelMissing = 6
+	ExitConnection           = 7
+	ExitSpreadViolation      = 8
+)
+
+// An ExitCoder is an error with a specific process exit code.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ExitCode returns the process exit code for the given error. Errors without
+// a specific exit code return ExitError.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitCoder ExitCoder
+	if errors.As(err, &exitCoder) {
+		return exitCoder.ExitCode()
+	}
+	return ExitError
+}
+
+// ErrUsage is returned for invalid command line arguments.
+type ErrUsage struct {
+	Err error
+}
+
+func (err ErrUsage) Error() string {
+	return err.Err.Error()
+}
+
+func (err ErrUsage) Unwrap() error {
+	return err.Err
+}
+
+func (err ErrUsage) ExitCode() int {
+	return ExitUsage
+}
+
+// ErrNotFound is returned when a requested object does not exist, or when no
+// objects match a label selector.
+type ErrNotFound struct {
+	Kind      string
+	Namespace string
+	Name      string
+	// Selector is the label selector no objects of the kind match. Name is
+	// ignored if it is set.
+	Selector string
+}
+
+func (err ErrNotFound) Error() string {
+	if err.Selector != "" {
+		if err.Namespace == "" {
+			return fmt.Sprintf("no %v match selector %v", err.Kind, err.Selector)
+		}
+		return fmt.Sprintf("no %v in namespace %v match selector %v", err.Kind, err.Namespace, err.Selector)
+	}
+	if err.Namespace == "" {
+		return fmt.Sprintf("%v %q not found", err.Kind, err.Name)
+	}
+	return fmt.Sprintf("%v %q not found in namespace %q", err.Kind, err.Name, err.Namespace)
+}
+
+func (err ErrNotFound) ExitCode() int {
+	return ExitNotFound
+}
+
+// ErrForbidden is returned when the API server rejects a request because the
+// user is not authenticated or not allowed to make it.
+type ErrForbidden struct {
+	Err error
+}
+
+func (err ErrForbidden) Error() string {
+	return fmt.Sprintf("access denied: %v", err.Err)
+}
+
+func (err ErrForbidden) Unwrap() error {
+	return err.Err
+}
+
+func (err ErrForbidden) ExitCode() int {
+	return ExitForbidden
+}
+
+// ErrUnscheduled is returned when a target pod has no node and no node it
+// could be scheduled on, or no node can stand in for it.
+type ErrUnscheduled struct {
+	Namespace         string
+	Name              string
+	NominatedNodeName string
+	// Reason explains why no node can be used instead (e.g. "has no
+	// nominated node"). It defaults to no node matching the pod's node
+	// selector, affinity and tolerations.
+	Reason string
+}
+
+func (err ErrUnscheduled) Error() string {
+	nominated := ""
+	if err.NominatedNodeName != "" {
+		nominated = fmt.Sprintf(" (nominated node: %v)", err.NominatedNodeName)
+	}
+	reason := err.Reason
+	if reason == "" {
+		reason = "no nodes match its node selector, affinity and tolerations"
+	}
+	return fmt.Sprintf("pod %v/%v is unscheduled%v and %v", err.Namespace, err.Name, nominated, reason)
+}
+
+func (err ErrUnscheduled) ExitCode() int {
+	return ExitUnscheduled
+}
+
+// ErrTopologyLabelMissing is returned when a node has none of the labels used
+// to find nearby nodes.
+type ErrTopologyLabelMissing struct {
+	Node string
+	Keys []string
+}
+
+func (err ErrTopologyLabelMissing) Error() string {
+	return fmt.Sprintf("unable to find label '%v' on node: %v", strings.Join(err.Keys, "' or '"), err.Node)
+}
+
+func (err ErrTopologyLabelMissing) ExitCode() int {
+	return ExitTopologyLabelMissing
+}
+
+// ErrConnection is returned when the API server can't be reached.
+type ErrConnection struct {
+	Err error
+}
+
+func (err ErrConnection) Error() string {
+	return fmt.Sprintf("unable to connect to the cluster: %v", err.Err)
+}
+
+func (err ErrConnection) Unwrap() error {
+	return err.Err
+}
+
+func (err ErrConnection) ExitCode() int {
+	return ExitConnection
+}
+
+// ErrSpreadViolation is returned when more pods are in a node, zone or region
+// than allowed.
+type ErrSpreadViolation struct {
+	// Violations describe each domain over its maximum.
+	Violations []string
+}
+
+func (err ErrSpreadViolation) Error() string {
+	return fmt.Sprintf("spread limits exceeded: %v", strings.Join(err.Violations, "; "))
+}
+
+func (err ErrSpreadViolation) ExitCode() int {
+	return ExitSpreadViolation
+}
+
+// ErrMetricsUnavailable is returned when the metrics.k8s.io API isn't served,
+// usually because metrics-server isn't installed.
+type ErrMetricsUnavailable struct {
+	Err error
+}
+
+func (err ErrMetricsUnavailable) Error() string {
+	return fmt.Sprintf("metrics API unavailable (is metrics-server installed?): %v", err.Err)
+}
+
+func (err ErrMetricsUnavailable) ExitCode() int {
+	return ExitError
+}
+
+func (err ErrMetricsUnavailable) Unwrap() error {
+	return err.Err
+}
+
+// ErrContexts is returned when a command run against several kubeconfig
+// contexts fails in some of them.
+type ErrContexts struct {
+	// Errs holds the error of each failed context, by context name.
+	Errs map[string]error
+	// Total is the number of contexts the command ran against.
+	Total int
+}
+
+func (err ErrContexts) Error() string {
+	names := []string{}
+	for name := range err.Errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	messages := []string{}
+	for _, name := range names {
+		messages = append(messages, fmt.Sprintf("%v: %v", name, err.Errs[name]))
+	}
+	return fmt.Sprintf("%v of %v contexts failed: %v", len(err.Errs), err.Total, strings.Join(messages, "; "))
+}
+
+// ExitCode returns the exit code shared by every failed context, otherwise
+// ExitError.
+func (err ErrContexts) ExitCode() int {
+	codes := map[int]bool{}
+	for _, contextErr := range err.Errs {
+		codes[ExitCode(contextErr)] = true
+	}
+	if len(codes) != 1 {
+		return ExitError
+	}
+	for code := range codes {
+		return code
+	}
+	return ExitError
+}
+
+// FromAPI converts an error returned by the Kubernetes API client for the
+// given object into one of the error types of this package. Other errors are
+// returned as is. The name may be empty for list requests.
+func FromAPI(err error, kind string, namespace string, name string) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case apierrors.IsNotFound(err) && name != "":
+		return ErrNotFound{Kind: kind, Namespace: namespace, Name: name}
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return ErrForbidden{Err: err}
+	}
+	// Transport failures (e.g. connection refused, DNS or TLS errors) are
+	// wrapped in a *url.Error, which implements net.Error.
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrConnection{Err: err}
+	}
+	return err
+}
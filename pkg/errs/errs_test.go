@@ -0,0 +1,136 @@
+package errs_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"syscall"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+func TestFromAPI(t *testing.T) {
+	podsResource := schema.GroupResource{Resource: "pods"}
+
+	t.Run("not found", func(t *testing.T) {
+		err := errs.FromAPI(apierrors.NewNotFound(podsResource, "nginx-abc123"), "pod", "default", "nginx-abc123")
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Fatalf("Expected error type: %T, got: %T", notFound, err)
+		}
+		want := `pod "nginx-abc123" not found in namespace "default"`
+		if err.Error() != want {
+			t.Errorf("Expected error message: %v, got: %v", want, err)
+		}
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		err := errs.FromAPI(apierrors.NewForbidden(podsResource, "nginx-abc123", fmt.Errorf("no RBAC policy matched")), "pod", "default", "nginx-abc123")
+		var forbidden errs.ErrForbidden
+		if !errors.As(err, &forbidden) {
+			t.Errorf("Expected error type: %T, got: %T", forbidden, err)
+		}
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		err := errs.FromAPI(apierrors.NewUnauthorized("token expired"), "pods", "default", "")
+		var forbidden errs.ErrForbidden
+		if !errors.As(err, &forbidden) {
+			t.Errorf("Expected error type: %T, got: %T", forbidden, err)
+		}
+	})
+
+	t.Run("connection refused", func(t *testing.T) {
+		transportErr := &url.Error{Op: "Get", URL: "https://127.0.0.1:6443/api/v1/pods", Err: syscall.ECONNREFUSED}
+		err := errs.FromAPI(transportErr, "pods", "default", "")
+		var connection errs.ErrConnection
+		if !errors.As(err, &connection) {
+			t.Errorf("Expected error type: %T, got: %T", connection, err)
+		}
+	})
+
+	t.Run("other errors are returned as is", func(t *testing.T) {
+		original := fmt.Errorf("something else")
+		err := errs.FromAPI(original, "pod", "default", "nginx-abc123")
+		if err != original {
+			t.Errorf("Expected the original error, got: %v", err)
+		}
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	var testCases = []struct {
+		err  error
+		code int
+	}{
+		{nil, errs.ExitOK},
+		{fmt.Errorf("something else"), errs.ExitError},
+		{errs.ErrUsage{Err: fmt.Errorf("bad flag")}, errs.ExitUsage},
+		{errs.ErrNotFound{Kind: "pod", Name: "nginx-abc123"}, errs.ExitNotFound},
+		{errs.ErrForbidden{Err: fmt.Errorf("forbidden")}, errs.ExitForbidden},
+		{errs.ErrUnscheduled{Namespace: "default", Name: "nginx-abc123"}, errs.ExitUnscheduled},
+		{errs.ErrTopologyLabelMissing{Node: "node-a-1", Keys: []string{"topology.kubernetes.io/zone"}}, errs.ExitTopologyLabelMissing},
+		{errs.ErrConnection{Err: fmt.Errorf("connection refused")}, errs.ExitConnection},
+		{errs.ErrSpreadViolation{Violations: []string{"zone us-east4-a has 3 of 3 pods"}}, errs.ExitSpreadViolation},
+		// Contexts failing the same way keep the code, otherwise it's generic.
+		{errs.ErrContexts{Errs: map[string]error{"a": errs.ErrConnection{Err: fmt.Errorf("refused")}, "b": errs.ErrConnection{Err: fmt.Errorf("refused")}}, Total: 3}, errs.ExitConnection},
+		{errs.ErrContexts{Errs: map[string]error{"a": errs.ErrConnection{Err: fmt.Errorf("refused")}, "b": errs.ErrNotFound{Kind: "pod", Name: "nginx"}}, Total: 3}, errs.ExitError},
+		// Wrapped errors keep their exit code.
+		{fmt.Errorf("unable to fetch node: %w", errs.ErrNotFound{Kind: "node", Name: "node-a-1"}), errs.ExitNotFound},
+	}
+	for _, testCase := range testCases {
+		got := errs.ExitCode(testCase.err)
+		if got != testCase.code {
+			t.Errorf("Expected ExitCode(%v) to return: %v, got: %v", testCase.err, testCase.code, got)
+		}
+	}
+}
+
+func TestErrContexts(t *testing.T) {
+	err := errs.ErrContexts{
+		Errs: map[string]error{
+			"prod-west": fmt.Errorf("timeout"),
+			"prod-east": errs.ErrNotFound{Kind: "pod", Namespace: "default", Name: "nginx"},
+		},
+		Total: 6,
+	}
+	want := `2 of 6 contexts failed: prod-east: pod "nginx" not found in namespace "default"; prod-west: timeout`
+	if err.Error() != want {
+		t.Errorf("Expected error message: %v, got: %v", want, err)
+	}
+}
+
+func TestErrNotFound(t *testing.T) {
+	var testCases = []struct {
+		err  errs.ErrNotFound
+		want string
+	}{
+		{errs.ErrNotFound{Kind: "node", Name: "node-a-1"}, `node "node-a-1" not found`},
+		{errs.ErrNotFound{Kind: "pods", Namespace: "default", Selector: "app=web"}, "no pods in namespace default match selector app=web"},
+		{errs.ErrNotFound{Kind: "pods", Selector: "app=web"}, "no pods match selector app=web"},
+	}
+	for _, testCase := range testCases {
+		if testCase.err.Error() != testCase.want {
+			t.Errorf("Expected error message: %v, got: %v", testCase.want, testCase.err)
+		}
+	}
+}
+
+func TestErrUnscheduled(t *testing.T) {
+	var testCases = []struct {
+		err  errs.ErrUnscheduled
+		want string
+	}{
+		{errs.ErrUnscheduled{Namespace: "default", Name: "nginx", NominatedNodeName: "node-a-1"}, "pod default/nginx is unscheduled (nominated node: node-a-1) and no nodes match its node selector, affinity and tolerations"},
+		{errs.ErrUnscheduled{Namespace: "default", Name: "nginx", Reason: "has no nominated node"}, "pod default/nginx is unscheduled and has no nominated node"},
+	}
+	for _, testCase := range testCases {
+		if testCase.err.Error() != testCase.want {
+			t.Errorf("Expected error message: %v, got: %v", testCase.want, testCase.err)
+		}
+	}
+}
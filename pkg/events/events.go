@@ -0,0 +1,145 @@
+// Package events provides a CLI to list the events of a pod's node and its
+// neighbors.
+package events
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+// An EventsCLI is used to create a command line interface for listing the
+// events of a pod's node and the pods on it.
+type EventsCLI struct {
+	Client kubernetes.Interface
+}
+
+// ErrPodNameRequired is returned when no pod name is given.
+type ErrPodNameRequired struct{}
+
+func (err ErrPodNameRequired) Error() string {
+	return "a pod name is required"
+}
+
+func (err ErrPodNameRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// Execute writes a timeline of the events involving the pod's node and the
+// pods on it to the given io.Writer and returns an error.
+func (e *EventsCLI) Execute(args []string, writer io.Writer) error {
+	return e.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (e *EventsCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	var podName string
+	remainingArgs := args
+	if len(args) > 0 {
+		matched, err := regexp.MatchString("^-", args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing arguments")
+		}
+		if !matched {
+			podName = args[0]
+			remainingArgs = args[1:]
+		}
+	}
+
+	f := flag.NewFlagSet("kubectl nearby events", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "List the events of a pod's node and of every pod on it, oldest first.\n\nUSAGE\n\n  %s events POD [OPTIONS]\n\nOPTIONS\n\n", os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+	allNamespaces := f.Bool("all-namespaces", false, "Include the events of pods from all namespaces on the node")
+	reasons := f.String("reason", "", "Only list events with one of these comma-separated reasons (e.g. Evicted,OOMKilling,NodeNotReady)")
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	if podName == "" {
+		return ErrPodNameRequired{}
+	}
+
+	namespace, err := connection.CurrentNamespace()
+	if err != nil {
+		return err
+	}
+	if e.Client == nil {
+		e.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := nearby.EventsNearPod(ctx, e.Client, namespace, podName, nearby.PodOptions{AllNamespaces: *allNamespaces})
+	if err != nil {
+		return err
+	}
+
+	wanted := map[string]bool{}
+	for _, reason := range strings.Split(*reasons, ",") {
+		if reason = strings.TrimSpace(reason); reason != "" {
+			wanted[reason] = true
+		}
+	}
+	rows := [][]string{{"LAST SEEN", "TYPE", "REASON", "NAMESPACE", "OBJECT", "COUNT", "MESSAGE"}}
+	for _, event := range result.Events {
+		if len(wanted) > 0 && !wanted[event.Reason] {
+			continue
+		}
+		rows = append(rows, []string{
+			nearby.EventTime(event).UTC().Format(time.RFC3339),
+			output.DashIfEmpty(event.Type),
+			output.DashIfEmpty(event.Reason),
+			output.DashIfEmpty(event.InvolvedObject.Namespace),
+			strings.ToLower(event.InvolvedObject.Kind) + "/" + event.InvolvedObject.Name,
+			strconv.Itoa(int(nearby.EventCount(event))),
+			// Keep multi-line messages (e.g. from evictions) on their row.
+			output.DashIfEmpty(strings.Join(strings.Fields(event.Message), " ")),
+		})
+	}
+
+	header := fmt.Sprintf("Events near pod %v/%v on node %v", namespace, podName, result.Node)
+	if len(rows) == 1 {
+		_, err = fmt.Fprintf(writer, "%v: no events found\n", header)
+		if err != nil {
+			return fmt.Errorf("printing output: %v", err)
+		}
+		return nil
+	}
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	_, err = fmt.Fprintln(writer, fmt.Sprintf("%v: %v events", header, len(rows)-1)+"\n\n"+formatted)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return nil
+}
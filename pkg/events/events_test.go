@@ -0,0 +1,138 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/events"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	t.Run("with a pod, shows the events of its node and neighbors", func(t *testing.T) {
+		expected := `Events near pod testing-cluster-default/api-1 on node node-a-1: 4 events
+
+LAST SEEN             TYPE     REASON        NAMESPACE                OBJECT         COUNT  MESSAGE
+2024-01-01T11:50:00Z  Warning  OOMKilling    -                        node/node-a-1  1      Memory cgroup out of memory: Killed process 1234 (java)
+2024-01-01T11:51:00Z  Warning  Evicted       testing-cluster-default  pod/batch-1    1      The node was low on resource: memory. Container main was using 2Gi.
+2024-01-01T11:55:00Z  Warning  BackOff       testing-cluster-default  pod/api-1      3      Back-off restarting failed container
+2024-01-01T11:58:00Z  Normal   NodeNotReady  testing-cluster-default  pod/cache-1    1      Node is not ready
+`
+		got, err := execute([]string{"api-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with --reason, only shows events with those reasons", func(t *testing.T) {
+		expected := `Events near pod testing-cluster-default/api-1 on node node-a-1: 2 events
+
+LAST SEEN             TYPE     REASON      NAMESPACE                OBJECT         COUNT  MESSAGE
+2024-01-01T11:50:00Z  Warning  OOMKilling  -                        node/node-a-1  1      Memory cgroup out of memory: Killed process 1234 (java)
+2024-01-01T11:51:00Z  Warning  Evicted     testing-cluster-default  pod/batch-1    1      The node was low on resource: memory. Container main was using 2Gi.
+`
+		got, err := execute([]string{"api-1", "--reason", "Evicted,OOMKilling"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with a node without events, says so", func(t *testing.T) {
+		expected := "Events near pod testing-cluster-default/web-1 on node node-b-1: no events found\n"
+		got, err := execute([]string{"web-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an unknown pod, returns not found", func(t *testing.T) {
+		_, err := execute([]string{"missing"})
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+
+	t.Run("with an unscheduled pod, returns an unscheduled error", func(t *testing.T) {
+		_, err := execute([]string{"pending-1"})
+		if errs.ExitCode(err) != errs.ExitUnscheduled {
+			t.Errorf("Expected an unscheduled error, got: %v", err)
+		}
+	})
+
+	t.Run("with no pod, returns a usage error", func(t *testing.T) {
+		_, err := execute([]string{})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func execute(args []string) (string, error) {
+	return clienttest.Execute(&events.EventsCLI{Client: testClient()}, args)
+}
+
+// testClient returns a fake client where node-a-1 ran out of memory,
+// evicting batch-1 (now gone) and making the target api-1 crash loop, while
+// node-b-1 and its pod web-1 have no events. pending-1 is unscheduled.
+func testClient() kubernetes.Interface {
+	backOff := testEvent("testing-cluster-default", "Pod", "api-1", "Warning", "BackOff", "Back-off restarting failed container", "2024-01-01T11:55:00Z")
+	backOff.Count = 3
+	// The scheduler reports a pending pod that fits no node, which isn't
+	// about node-a-1.
+	failedScheduling := testEvent("testing-cluster-default", "Pod", "other-1", "Warning", "FailedScheduling", "0/3 nodes are available", "2024-01-01T11:52:00Z")
+	failedScheduling.Source = v1.EventSource{Component: "default-scheduler"}
+	return testclient.NewSimpleClientset(
+		clienttest.Pod("api-1", "node-a-1", nil),
+		clienttest.Pod("cache-1", "node-a-1", nil),
+		clienttest.Pod("web-1", "node-b-1", nil),
+		clienttest.Pod("pending-1", "", nil),
+		testEvent("default", "Node", "node-a-1", "Warning", "OOMKilling", "Memory cgroup out of memory: Killed process 1234 (java)", "2024-01-01T11:50:00Z"),
+		testEvent("default", "Node", "node-c-1", "Normal", "NodeNotReady", "Node is not ready", "2024-01-01T11:50:00Z"),
+		testEvent("testing-cluster-default", "Pod", "batch-1", "Warning", "Evicted", "The node was low on resource: memory.\nContainer main was using 2Gi. ", "2024-01-01T11:51:00Z"),
+		backOff,
+		testEvent("testing-cluster-default", "Pod", "cache-1", "Normal", "NodeNotReady", "Node is not ready", "2024-01-01T11:58:00Z"),
+		failedScheduling,
+	)
+}
+
+// testEvent returns an event about the object, last seen at the given RFC
+// 3339 time. Pod events are reported by node-a-1's kubelet.
+func testEvent(namespace string, kind string, name string, eventType string, reason string, message string, lastSeen string) *v1.Event {
+	last, err := time.Parse(time.RFC3339, lastSeen)
+	if err != nil {
+		panic(err)
+	}
+	involved := v1.ObjectReference{Kind: kind, Name: name}
+	source := v1.EventSource{Component: "kubelet", Host: name}
+	if kind == "Pod" {
+		involved.Namespace = namespace
+		source.Host = "node-a-1"
+	}
+	return &v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name + "." + reason, Namespace: namespace},
+		InvolvedObject: involved,
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         source,
+		LastTimestamp:  metav1.NewTime(last),
+		Count:          1,
+	}
+}
@@ -0,0 +1,81 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// Distances other than the topology levels shared by two pods.
+const (
+	// DistanceNone is the distance between pods in different regions.
+	DistanceNone = "none"
+	// DistanceUnknown is the distance to an unscheduled pod or a pod whose
+	// node is missing the topology labels needed to compare it.
+	DistanceUnknown = "unknown"
+)
+
+// A Location is where a pod runs. Zone and Region are empty if the pod's node
+// is missing the label (or the pod is unscheduled).
+type Location struct {
+	Pod    v1.Pod
+	Node   string
+	Zone   string
+	Region string
+}
+
+// Locate returns the location of each pod, fetching each of their nodes
+// once.
+func Locate(ctx context.Context, client kubernetes.Interface, pods []v1.Pod) ([]Location, error) {
+	zoneKeys, _ := topology.Keys(topology.LevelZone)
+	regionKeys, _ := topology.Keys(topology.LevelRegion)
+	nodes := map[string]*v1.Node{}
+	locations := []Location{}
+	for _, pod := range pods {
+		location := Location{Pod: pod, Node: pod.Spec.NodeName}
+		if location.Node != "" {
+			node, ok := nodes[location.Node]
+			if !ok {
+				var err error
+				node, err = client.CoreV1().Nodes().Get(ctx, location.Node, metav1.GetOptions{})
+				if err != nil {
+					return nil, fmt.Errorf("unable to fetch node: %w", errs.FromAPI(err, "node", "", location.Node))
+				}
+				nodes[location.Node] = node
+			}
+			location.Zone, _, _ = topology.Value(node.Labels, zoneKeys)
+			location.Region, _, _ = topology.Value(node.Labels, regionKeys)
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+// Distance returns the closest topology level shared by the two locations:
+// topology.LevelNode, topology.LevelZone or topology.LevelRegion. Otherwise, it
+// returns DistanceNone if they are in different regions and DistanceUnknown
+// if that can't be told. Zones only match within the same region when both
+// regions are known, since some providers reuse zone names (e.g. "1") across
+// regions.
+func Distance(a Location, b Location) string {
+	sameRegion := a.Region == "" || b.Region == "" || a.Region == b.Region
+	switch {
+	case a.Node == "" || b.Node == "":
+		return DistanceUnknown
+	case a.Node == b.Node:
+		return topology.LevelNode
+	case a.Zone != "" && a.Zone == b.Zone && sameRegion:
+		return topology.LevelZone
+	case a.Region != "" && a.Region == b.Region:
+		return topology.LevelRegion
+	case a.Region == "" || b.Region == "":
+		return DistanceUnknown
+	}
+	return DistanceNone
+}
@@ -0,0 +1,69 @@
+package nearby_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestDistance(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a", "topology.kubernetes.io/region": "us-east4"}),
+		testNode("node-a-2", map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east4-a", "failure-domain.beta.kubernetes.io/region": "us-east4"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b", "topology.kubernetes.io/region": "us-east4"}),
+		testNode("node-c-1", map[string]string{"topology.kubernetes.io/zone": "us-west1-a", "topology.kubernetes.io/region": "us-west1"}),
+		testNode("node-unlabeled", nil),
+		testNode("node-westeurope-1", map[string]string{"topology.kubernetes.io/zone": "1", "topology.kubernetes.io/region": "westeurope"}),
+		testNode("node-eastus-1", map[string]string{"topology.kubernetes.io/zone": "1", "topology.kubernetes.io/region": "eastus"}),
+		testNode("node-zone-only-1", map[string]string{"topology.kubernetes.io/zone": "1"}),
+	)
+	locations, err := nearby.Locate(context.Background(), client, []v1.Pod{
+		*nodePod("default", "nginx", "node-a-1"),
+		*nodePod("default", "redis", "node-a-1"),
+		*nodePod("default", "web", "node-a-2"),
+		*nodePod("default", "api", "node-b-1"),
+		*nodePod("default", "batch", "node-c-1"),
+		*nodePod("default", "cron", "node-unlabeled"),
+		*nodePod("default", "pending", ""),
+		*nodePod("default", "west", "node-westeurope-1"),
+		*nodePod("default", "east", "node-eastus-1"),
+		*nodePod("default", "zone-only", "node-zone-only-1"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if locations[2].Zone != "us-east4-a" || locations[2].Region != "us-east4" {
+		t.Errorf("Expected the deprecated labels to be used, got: %+v", locations[2])
+	}
+
+	var testCases = []struct {
+		a        int
+		b        int
+		expected string
+	}{
+		{0, 1, "node"},
+		{0, 2, "zone"},
+		{0, 3, "region"},
+		{0, 4, nearby.DistanceNone},
+		{0, 5, nearby.DistanceUnknown},
+		{0, 6, nearby.DistanceUnknown},
+		// The same zone name in different regions isn't the same zone.
+		{7, 8, nearby.DistanceNone},
+		// Without a region to tell them apart, the zone names are trusted.
+		{7, 9, "zone"},
+	}
+	for _, testCase := range testCases {
+		a, b := locations[testCase.a], locations[testCase.b]
+		got := nearby.Distance(a, b)
+		if got != testCase.expected {
+			t.Errorf("Expected distance between %v and %v: %v, got: %v", a.Pod.Name, b.Pod.Name, testCase.expected, got)
+		}
+		if nearby.Distance(b, a) != got {
+			t.Errorf("Expected distance between %v and %v to be symmetric", a.Pod.Name, b.Pod.Name)
+		}
+	}
+}
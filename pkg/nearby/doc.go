@@ -0,0 +1,4 @@
+// Package nearby finds the pods and nodes near a pod or node. It is the
+// library behind the kubectl-nearby commands and can be used on its own with
+// any kubernetes.Interface.
+package nearby
@@ -0,0 +1,198 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// A DrainPod is a pod on a node being drained.
+type DrainPod struct {
+	Pod      v1.Pod
+	Workload Workload
+	// Unmanaged is true if the pod has no controller to recreate it.
+	Unmanaged bool
+	// LocalStorage is true if the pod has an emptyDir volume, whose data is
+	// lost on eviction.
+	LocalStorage bool
+	// Mirror is true if the pod is the API server's mirror of a static pod
+	// run by the node's kubelet, which can't be evicted.
+	Mirror bool
+	// DisruptionBudgets are the names of the violated PodDisruptionBudgets
+	// that would refuse to evict the pod.
+	DisruptionBudgets []string
+}
+
+// DaemonSet returns true if the pod belongs to a DaemonSet. Like kubectl
+// drain, these pods are skipped rather than evicted.
+func (pod DrainPod) DaemonSet() bool {
+	return pod.Workload.Kind == KindDaemonSet
+}
+
+// Skipped returns true if kubectl drain leaves the pod on the node: it
+// belongs to a DaemonSet or is a mirror pod.
+func (pod DrainPod) Skipped() bool {
+	return pod.DaemonSet() || pod.Mirror
+}
+
+// Finished returns true if the pod has succeeded or failed. kubectl drain
+// deletes these pods, so they are neither evicted nor blocked.
+func (pod DrainPod) Finished() bool {
+	return pod.Pod.Status.Phase == v1.PodSucceeded || pod.Pod.Status.Phase == v1.PodFailed
+}
+
+// Blocked returns the reasons kubectl drain would refuse or fail to evict the
+// pod without extra flags, or nil if it wouldn't. Pods that have finished or
+// are skipped are never blocked.
+func (pod DrainPod) Blocked() []string {
+	if pod.Skipped() || pod.Finished() {
+		return nil
+	}
+	var reasons []string
+	if pod.Unmanaged {
+		reasons = append(reasons, "no controller (needs --force)")
+	}
+	if pod.LocalStorage {
+		reasons = append(reasons, "emptyDir volume (needs --delete-emptydir-data)")
+	}
+	for _, name := range pod.DisruptionBudgets {
+		reasons = append(reasons, fmt.Sprintf("PodDisruptionBudget %v", name))
+	}
+	return reasons
+}
+
+// A DrainResult is the impact of draining a node.
+type DrainResult struct {
+	Node string
+	// Pods on the node, sorted by namespace and name.
+	Pods []DrainPod
+	// DisruptionBudgets matching pods on the node, sorted by namespace and
+	// name.
+	DisruptionBudgets []DisruptionBudgetImpact
+}
+
+// DrainImpact lists the pods on the node and predicts which of them would
+// block a drain, from the current pods and PodDisruptionBudgets. A budget is
+// violated if evicting every matching pod on the node leaves fewer ready pods
+// than it allows; pods are not assumed to be rescheduled in the meantime, and
+// skipped pods (see DrainPod.Skipped) stay on the node. The
+// pods on the node are evicted in name order, so the budget blocks the last
+// ones.
+func DrainImpact(ctx context.Context, client kubernetes.Interface, nodeName string) (*DrainResult, error) {
+	_, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch node: %w", errs.FromAPI(err, "node", "", nodeName))
+	}
+	pods, err := PodsOnNode(ctx, client, "", nodeName)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := client.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch replica sets: %w", errs.FromAPI(err, "replicasets", "", ""))
+	}
+	owners := replicaSetOwners(replicaSets.Items)
+
+	result := &DrainResult{Node: nodeName}
+	for _, pod := range pods {
+		workload := WorkloadOf(pod, owners)
+		_, mirror := pod.Annotations[v1.MirrorPodAnnotationKey]
+		drainPod := DrainPod{Pod: pod, Workload: workload, Unmanaged: workload.Kind == KindPod, Mirror: mirror}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil {
+				drainPod.LocalStorage = true
+			}
+		}
+		result.Pods = append(result.Pods, drainPod)
+	}
+	sort.Slice(result.Pods, func(i, j int) bool {
+		a, b := result.Pods[i].Pod, result.Pods[j].Pod
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	budgets, err := client.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pod disruption budgets: %w", errs.FromAPI(err, "poddisruptionbudgets", "", ""))
+	}
+	skipped := map[types.NamespacedName]bool{}
+	for _, pod := range result.Pods {
+		if pod.Skipped() {
+			skipped[types.NamespacedName{Namespace: pod.Pod.Namespace, Name: pod.Pod.Name}] = true
+		}
+	}
+	lost := func(pod v1.Pod) bool {
+		return pod.Spec.NodeName == nodeName && !skipped[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}]
+	}
+	for _, budget := range budgets.Items {
+		if budget.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for pod disruption budget %v/%v: %v", budget.Namespace, budget.Name, err)
+		}
+		matched := false
+		for _, pod := range result.Pods {
+			if !pod.Skipped() && pod.Pod.Namespace == budget.Namespace && selector.Matches(labels.Set(pod.Pod.Labels)) {
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		budgetPods, err := client.CoreV1().Pods(budget.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch pods for pod disruption budget %v: %w", budget.Name, errs.FromAPI(err, "pods", budget.Namespace, ""))
+		}
+		active := []v1.Pod{}
+		for _, pod := range budgetPods.Items {
+			if isActive(pod) {
+				active = append(active, pod)
+			}
+		}
+		impact, ok, err := disruptionBudgetImpact(budget, active, lost)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		result.DisruptionBudgets = append(result.DisruptionBudgets, impact)
+		if !impact.Violated() {
+			continue
+		}
+		// The budget allows some ready pods to be evicted before it blocks
+		// the rest. Evicting pods that aren't ready doesn't use it up.
+		allowed := max(impact.Healthy-impact.DesiredHealthy, 0)
+		for i, pod := range result.Pods {
+			if pod.Skipped() || pod.Pod.Namespace != budget.Namespace || !selector.Matches(labels.Set(pod.Pod.Labels)) || !isReady(pod.Pod) {
+				continue
+			}
+			if allowed > 0 {
+				allowed--
+				continue
+			}
+			result.Pods[i].DisruptionBudgets = append(result.Pods[i].DisruptionBudgets, budget.Name)
+		}
+	}
+	sort.Slice(result.DisruptionBudgets, func(i, j int) bool {
+		a, b := result.DisruptionBudgets[i], result.DisruptionBudgets[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return result, nil
+}
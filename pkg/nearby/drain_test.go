@@ -0,0 +1,109 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestDrainImpact(t *testing.T) {
+	client := outageClient()
+	ctx := context.Background()
+	cache := readyPod("cache-1", "node-a-1", "ReplicaSet", "web-abc")
+	cache.Spec.Volumes = []v1.Volume{{Name: "scratch", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+	minAvailable := intstr.FromInt32(3)
+	etcdBudget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "etcd"}},
+		},
+	}
+	// Static pods are mirrored in the API, owned by their node.
+	mirror := readyPod("kube-proxy-node-a-1", "node-a-1", "Node", "node-a-1")
+	mirror.Annotations = map[string]string{v1.MirrorPodAnnotationKey: "abc123"}
+	backup := readyPod("backup-1", "node-a-1", "Job", "backup")
+	backup.Status.Phase = v1.PodSucceeded
+	for _, pod := range []*v1.Pod{cache, mirror, backup} {
+		_, err := client.CoreV1().Pods("default").Create(ctx, pod, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// The DaemonSet and mirror pods stay on the node, so their budget isn't
+	// affected by the drain.
+	systemBudget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"agent", "node-a-1"}},
+			}},
+		},
+	}
+	for _, budget := range []*policyv1.PodDisruptionBudget{etcdBudget, systemBudget} {
+		_, err := client.PolicyV1().PodDisruptionBudgets("default").Create(ctx, budget, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	result, err := nearby.DrainImpact(ctx, client, "node-a-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string][]string{
+		"agent-a-1": nil,
+		"api-abc-1": nil,
+		"backup-1":  nil,
+		"cache-1":   {"emptyDir volume (needs --delete-emptydir-data)"},
+		"debug":     {"no controller (needs --force)"},
+		"etcd-0":    {"PodDisruptionBudget etcd"},
+		"web-abc-1": nil,
+		// Mirror pods are skipped, not blocked for lacking a controller.
+		"kube-proxy-node-a-1": nil,
+	}
+	got := map[string][]string{}
+	for _, pod := range result.Pods {
+		got[pod.Pod.Name] = pod.Blocked()
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected blocked pods:\n%v\ngot:\n%v", expected, got)
+	}
+	if !result.Pods[0].DaemonSet() {
+		t.Errorf("Expected %v to belong to a DaemonSet", result.Pods[0].Pod.Name)
+	}
+	for _, pod := range result.Pods {
+		if pod.Mirror != (pod.Pod.Name == "kube-proxy-node-a-1") || pod.Skipped() != (pod.Mirror || pod.DaemonSet()) {
+			t.Errorf("Expected only kube-proxy-node-a-1 to be a mirror pod, got: %v (mirror: %v, skipped: %v)", pod.Pod.Name, pod.Mirror, pod.Skipped())
+		}
+	}
+	for _, pod := range result.Pods {
+		if pod.Finished() != (pod.Pod.Name == "backup-1") {
+			t.Errorf("Expected only backup-1 to have finished, got: %v (finished: %v)", pod.Pod.Name, pod.Finished())
+		}
+	}
+	expectedBudgets := []nearby.DisruptionBudgetImpact{
+		{Namespace: "default", Name: "api", Healthy: 2, Remaining: 1, DesiredHealthy: 1},
+		{Namespace: "default", Name: "etcd", Healthy: 3, Remaining: 2, DesiredHealthy: 3},
+		{Namespace: "default", Name: "web", Healthy: 3, Remaining: 1, DesiredHealthy: 1},
+	}
+	if !reflect.DeepEqual(result.DisruptionBudgets, expectedBudgets) {
+		t.Errorf("Expected disruption budgets:\n%+v\ngot:\n%+v", expectedBudgets, result.DisruptionBudgets)
+	}
+
+	_, err = nearby.DrainImpact(ctx, client, "node-z-1")
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+	}
+}
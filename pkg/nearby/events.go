@@ -0,0 +1,153 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// EventsResult holds the events involving a pod's node and the pods on it.
+type EventsResult struct {
+	Target v1.Pod
+	Node   string
+	// Events are sorted by EventTime.
+	Events []v1.Event
+}
+
+// EventsNearPod returns the events involving the given pod, its node and the
+// pods on that node, in the pod's namespace or in all namespaces if
+// opts.AllNamespaces is true. The events of the other pods are the ones
+// reported by the node's kubelet, so they are included even if the pod is
+// gone (e.g. after an eviction). opts.Topology is ignored. An
+// errs.ErrUnscheduled is returned if the pod has no node.
+func EventsNearPod(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts PodOptions) (*EventsResult, error) {
+	target, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errs.FromAPI(err, "pod", namespace, name)
+	}
+	nodeName := target.Spec.NodeName
+	if nodeName == "" {
+		return nil, errs.ErrUnscheduled{Namespace: namespace, Name: name, Reason: "has no co-located pods"}
+	}
+
+	listNamespace := namespace
+	if opts.AllNamespaces {
+		listNamespace = metav1.NamespaceAll
+	}
+	result := &EventsResult{Target: *target, Node: nodeName}
+	seen := map[types.NamespacedName]bool{}
+	add := func(events []v1.Event) {
+		for _, event := range events {
+			key := types.NamespacedName{Namespace: event.Namespace, Name: event.Name}
+			if !seen[key] {
+				seen[key] = true
+				result.Events = append(result.Events, event)
+			}
+		}
+	}
+	// Node events are recorded in the default namespace by the kubelet, but
+	// other components may use another one.
+	nodeEvents, err := listEvents(ctx, client, metav1.NamespaceAll, fields.Set{"involvedObject.kind": "Node", "involvedObject.name": nodeName})
+	if err != nil {
+		return nil, err
+	}
+	add(nodeEvents)
+	targetEvents, err := listEvents(ctx, client, namespace, fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": name})
+	if err != nil {
+		return nil, err
+	}
+	add(targetEvents)
+	// Events can't be selected by the host that reported them, so the
+	// kubelets' pod events are filtered here.
+	kubeletEvents, err := listEvents(ctx, client, listNamespace, fields.Set{"involvedObject.kind": "Pod", "source": "kubelet"})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range kubeletEvents {
+		if event.Source.Host == nodeName {
+			add([]v1.Event{event})
+		}
+	}
+
+	sort.SliceStable(result.Events, func(i, j int) bool {
+		a, b := result.Events[i], result.Events[j]
+		if timeA, timeB := EventTime(a), EventTime(b); !timeA.Equal(timeB) {
+			return timeA.Before(timeB)
+		}
+		if a.InvolvedObject.Namespace != b.InvolvedObject.Namespace {
+			return a.InvolvedObject.Namespace < b.InvolvedObject.Namespace
+		}
+		return a.InvolvedObject.Name < b.InvolvedObject.Name
+	})
+	return result, nil
+}
+
+// listEvents returns the events in the namespace matching the field
+// selector.
+func listEvents(ctx context.Context, client kubernetes.Interface, namespace string, selector fields.Set) ([]v1.Event, error) {
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %v events: %w", selector["involvedObject.kind"], errs.FromAPI(err, "events", namespace, ""))
+	}
+	matching := []v1.Event{}
+	for _, event := range events.Items {
+		// Not every client honors field selectors (e.g. the fake
+		// clientset), so check them here as well.
+		if selector.AsSelector().Matches(eventFields(event)) {
+			matching = append(matching, event)
+		}
+	}
+	return matching, nil
+}
+
+// eventFields returns the selectable fields of the event used by
+// listEvents. Like the API server, the source is the reporting component.
+func eventFields(event v1.Event) fields.Set {
+	source := event.Source.Component
+	if source == "" {
+		source = event.ReportingController
+	}
+	return fields.Set{
+		"involvedObject.kind": event.InvolvedObject.Kind,
+		"involvedObject.name": event.InvolvedObject.Name,
+		"source":              source,
+	}
+}
+
+// EventTime returns when the event last happened: its last timestamp, or its
+// event time or first timestamp for events that don't set one.
+func EventTime(event v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// EventCount returns how many times the event happened.
+func EventCount(event v1.Event) int32 {
+	switch {
+	case event.Series != nil:
+		return event.Series.Count
+	case event.Count > 0:
+		return event.Count
+	}
+	return 1
+}
@@ -0,0 +1,139 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestEventsNearPod(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Events with a host are reported by its kubelet, the others by the
+	// scheduler.
+	event := func(namespace string, kind string, name string, reason string, host string, at time.Time) *v1.Event {
+		source := v1.EventSource{Component: "default-scheduler"}
+		if host != "" {
+			source = v1.EventSource{Component: "kubelet", Host: host}
+		}
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name + "." + reason, Namespace: namespace},
+			InvolvedObject: v1.ObjectReference{Kind: kind, Namespace: namespace, Name: name},
+			Reason:         reason,
+			Source:         source,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	client := testclient.NewSimpleClientset(
+		nodePod("default", "nginx", "node-a-1"),
+		nodePod("default", "redis", "node-a-1"),
+		nodePod("kube-system", "fluentd", "node-a-1"),
+		nodePod("default", "web", "node-a-2"),
+		nodePod("default", "pending", ""),
+		event("default", "Node", "node-a-1", "NodeNotReady", "", start.Add(2*time.Minute)),
+		event("default", "Node", "node-a-2", "NodeNotReady", "", start),
+		event("default", "Pod", "redis", "OOMKilling", "node-a-1", start.Add(time.Minute)),
+		event("default", "Pod", "batch", "Evicted", "node-a-1", start),
+		event("default", "Pod", "web", "BackOff", "node-a-2", start),
+		event("kube-system", "Pod", "fluentd", "Killing", "node-a-1", start.Add(3*time.Minute)),
+		event("default", "Pod", "nginx", "Scheduled", "", start.Add(-time.Minute)),
+		event("default", "Pod", "redis", "Scheduled", "", start.Add(-time.Minute)),
+	)
+
+	var testCases = []struct {
+		name     string
+		opts     nearby.PodOptions
+		expected []string
+	}{
+		{
+			"defaults to the pod's namespace",
+			nearby.PodOptions{},
+			[]string{"nginx.Scheduled", "batch.Evicted", "redis.OOMKilling", "node-a-1.NodeNotReady"},
+		},
+		{
+			"with all namespaces",
+			nearby.PodOptions{AllNamespaces: true},
+			[]string{"nginx.Scheduled", "batch.Evicted", "redis.OOMKilling", "node-a-1.NodeNotReady", "fluentd.Killing"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := nearby.EventsNearPod(context.Background(), client, "default", "nginx", testCase.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := []string{}
+			for _, event := range result.Events {
+				got = append(got, event.Name)
+			}
+			if !reflect.DeepEqual(testCase.expected, got) {
+				t.Errorf("Expected events: %v, got: %v", testCase.expected, got)
+			}
+		})
+	}
+
+	t.Run("selects the events of the node, the pod and the kubelets", func(t *testing.T) {
+		client.ClearActions()
+		_, err := nearby.EventsNearPod(context.Background(), client, "default", "nginx", nearby.PodOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		selectors := []string{}
+		for _, action := range client.Actions() {
+			if list, ok := action.(clienttesting.ListAction); ok && action.GetResource().Resource == "events" {
+				selectors = append(selectors, list.GetNamespace()+" "+list.GetListRestrictions().Fields.String())
+			}
+		}
+		expected := []string{
+			" involvedObject.kind=Node,involvedObject.name=node-a-1",
+			"default involvedObject.kind=Pod,involvedObject.name=nginx",
+			"default involvedObject.kind=Pod,source=kubelet",
+		}
+		if !reflect.DeepEqual(expected, selectors) {
+			t.Errorf("Expected event lists: %v, got: %v", expected, selectors)
+		}
+	})
+
+	t.Run("with an unscheduled pod, returns an error", func(t *testing.T) {
+		_, err := nearby.EventsNearPod(context.Background(), client, "default", "pending", nearby.PodOptions{})
+		var unscheduled errs.ErrUnscheduled
+		if !errors.As(err, &unscheduled) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", unscheduled, err, err)
+		}
+	})
+}
+
+func TestEventTime(t *testing.T) {
+	first := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	last := first.Add(time.Hour)
+	var testCases = []struct {
+		name     string
+		event    v1.Event
+		expected time.Time
+		count    int32
+	}{
+		{"with a last timestamp", v1.Event{FirstTimestamp: metav1.NewTime(first), LastTimestamp: metav1.NewTime(last), Count: 4}, last, 4},
+		{"with an event series", v1.Event{EventTime: metav1.NewMicroTime(first), Series: &v1.EventSeries{Count: 2, LastObservedTime: metav1.NewMicroTime(last)}}, last, 2},
+		{"with only an event time", v1.Event{EventTime: metav1.NewMicroTime(first)}, first, 1},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if got := nearby.EventTime(testCase.event); !got.Equal(testCase.expected) {
+				t.Errorf("Expected time: %v, got: %v", testCase.expected, got)
+			}
+			if got := nearby.EventCount(testCase.event); got != testCase.count {
+				t.Errorf("Expected count: %v, got: %v", testCase.count, got)
+			}
+		})
+	}
+}
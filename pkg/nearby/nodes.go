@@ -0,0 +1,173 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// NodeOptions configure how nearby nodes are found.
+type NodeOptions struct {
+	// Topology is how far "nearby" reaches: topology.LevelZone (the
+	// default), topology.LevelRegion or a node label key.
+	Topology string
+	// TopologyKeys are node label keys tried in order. They override the
+	// keys of Topology.
+	TopologyKeys []string
+}
+
+// NodesResult holds the nodes near a node.
+type NodesResult struct {
+	Node v1.Node
+	// Nodes share the node's topology value, including the node itself.
+	Nodes []v1.Node
+	// TopologyKeys are the node label keys used to find nearby nodes.
+	TopologyKeys []string
+	// TopologyValue is the value of the first of TopologyKeys found on the
+	// node.
+	TopologyValue string
+}
+
+// NodesNearNode returns the nodes near the given node.
+func NodesNearNode(ctx context.Context, client kubernetes.Interface, name string, opts NodeOptions) (*NodesResult, error) {
+	keys := opts.TopologyKeys
+	if len(keys) == 0 {
+		level := opts.Topology
+		if level == "" {
+			level = topology.LevelZone
+		}
+		var err error
+		keys, err = topology.Keys(level)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nodes := &nodeCache{client: client}
+	value, nearbyNodes, err := nodesSharingTopology(ctx, client, nodes, name, keys)
+	if err != nil {
+		return nil, err
+	}
+	result := &NodesResult{
+		Nodes:         nearbyNodes,
+		TopologyKeys:  keys,
+		TopologyValue: value,
+	}
+	for _, node := range nearbyNodes {
+		if node.Name == name {
+			result.Node = node
+		}
+	}
+	return result, nil
+}
+
+// NodesNearPod returns the nodes near the node of the given pod. For an
+// unscheduled pod, its nominated node is used if it has one, otherwise an
+// errs.ErrUnscheduled is returned.
+func NodesNearPod(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts NodeOptions) (*NodesResult, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errs.FromAPI(err, "pod", namespace, name)
+	}
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		nodeName = pod.Status.NominatedNodeName
+	}
+	if nodeName == "" {
+		return nil, errs.ErrUnscheduled{Namespace: namespace, Name: name, Reason: "has no nominated node"}
+	}
+	return NodesNearNode(ctx, client, nodeName, opts)
+}
+
+// nodesSharingTopology returns the topology value of the named node and the
+// nodes sharing it. Rather than listing every node, the nodes labeled with
+// each of the keys and the value are listed with a label selector (e.g. the
+// current and the deprecated zone label), then filtered here so that a node
+// is matched by the first of the keys it is labeled with.
+func nodesSharingTopology(ctx context.Context, client kubernetes.Interface, nodes *nodeCache, name string, keys []string) (string, []v1.Node, error) {
+	node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable fetch node: %w", errs.FromAPI(err, "node", "", name))
+	}
+	value, _, ok := topology.Value(node.Labels, keys)
+	if !ok {
+		return "", nil, errs.ErrTopologyLabelMissing{Node: node.Name, Keys: keys}
+	}
+
+	candidates := []v1.Node{}
+	listed := map[string]bool{}
+	for _, key := range keys {
+		selected, err := nodes.listSelected(ctx, labels.Set{key: value}.String())
+		if err != nil {
+			return "", nil, err
+		}
+		for _, node := range selected {
+			if !listed[node.Name] {
+				listed[node.Name] = true
+				candidates = append(candidates, node)
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+	nearbyNodes := []v1.Node{}
+	for _, node := range candidates {
+		nodeValue, _, ok := topology.Value(node.Labels, keys)
+		if ok && nodeValue == value {
+			nearbyNodes = append(nearbyNodes, node)
+		}
+	}
+	return value, nearbyNodes, nil
+}
+
+// nodeCache lists the cluster's nodes, and the nodes matching each label
+// selector, at most once.
+type nodeCache struct {
+	client   kubernetes.Interface
+	nodes    []v1.Node
+	selected map[string][]v1.Node
+}
+
+func (c *nodeCache) list(ctx context.Context) ([]v1.Node, error) {
+	if c.nodes != nil {
+		return c.nodes, nil
+	}
+	nodeList, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch nodes: %w", errs.FromAPI(err, "nodes", "", ""))
+	}
+	c.nodes = nodeList.Items
+	return c.nodes, nil
+}
+
+func (c *nodeCache) listSelected(ctx context.Context, selector string) ([]v1.Node, error) {
+	if nodes, ok := c.selected[selector]; ok {
+		return nodes, nil
+	}
+	nodeList, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch nodes: %w", errs.FromAPI(err, "nodes", "", ""))
+	}
+	if c.selected == nil {
+		c.selected = map[string][]v1.Node{}
+	}
+	c.selected[selector] = nodeList.Items
+	return nodeList.Items, nil
+}
+
+// nodeNamesOf returns the sorted names of the nodes.
+func nodeNamesOf(nodes []v1.Node) []string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,125 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+func TestNodesNearNode(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a", "topology.kubernetes.io/region": "us-east4"}),
+		testNode("node-a-2", map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east4-a", "topology.kubernetes.io/region": "us-east4"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b", "topology.kubernetes.io/region": "us-east4"}),
+		testNode("node-unlabeled", nil),
+	)
+
+	var testCases = []struct {
+		name     string
+		opts     nearby.NodeOptions
+		expected []string
+		value    string
+	}{
+		{"defaults to the zone", nearby.NodeOptions{}, []string{"node-a-1", "node-a-2"}, "us-east4-a"},
+		{"with the region topology", nearby.NodeOptions{Topology: topology.LevelRegion}, []string{"node-a-1", "node-a-2", "node-b-1"}, "us-east4"},
+		{"with topology keys", nearby.NodeOptions{TopologyKeys: []string{"topology.kubernetes.io/zone"}}, []string{"node-a-1"}, "us-east4-a"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := nearby.NodesNearNode(context.Background(), client, "node-a-1", testCase.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got := []string{}
+			for _, node := range result.Nodes {
+				got = append(got, node.Name)
+			}
+			if len(got) != len(testCase.expected) {
+				t.Fatalf("Expected nodes: %v, got: %v", testCase.expected, got)
+			}
+			for i := range got {
+				if got[i] != testCase.expected[i] {
+					t.Errorf("Expected nodes: %v, got: %v", testCase.expected, got)
+				}
+			}
+			if result.Node.Name != "node-a-1" {
+				t.Errorf("Expected node: node-a-1, got: %v", result.Node.Name)
+			}
+			if result.TopologyValue != testCase.value {
+				t.Errorf("Expected topology value: %v, got: %v", testCase.value, result.TopologyValue)
+			}
+		})
+	}
+
+	t.Run("lists nodes with a label selector for each topology key", func(t *testing.T) {
+		client.ClearActions()
+		_, err := nearby.NodesNearNode(context.Background(), client, "node-a-1", nearby.NodeOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		selectors := []string{}
+		for _, action := range client.Actions() {
+			if list, ok := action.(k8stesting.ListAction); ok && action.GetResource().Resource == "nodes" {
+				selectors = append(selectors, list.GetListRestrictions().Labels.String())
+			}
+		}
+		expected := []string{"topology.kubernetes.io/zone=us-east4-a", "failure-domain.beta.kubernetes.io/zone=us-east4-a"}
+		if !reflect.DeepEqual(expected, selectors) {
+			t.Errorf("Expected node list selectors: %v, got: %v", expected, selectors)
+		}
+	})
+
+	t.Run("with a node missing the topology label, returns an error", func(t *testing.T) {
+		_, err := nearby.NodesNearNode(context.Background(), client, "node-unlabeled", nearby.NodeOptions{})
+		var missing errs.ErrTopologyLabelMissing
+		if !errors.As(err, &missing) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", missing, err, err)
+		}
+	})
+
+	t.Run("with an unknown node, returns a not found error", func(t *testing.T) {
+		_, err := nearby.NodesNearNode(context.Background(), client, "missing", nearby.NodeOptions{})
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+}
+
+func TestNodesNearPod(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+		nodePod("default", "nginx", "node-a-2"),
+		nodePod("default", "pending", ""),
+	)
+
+	result, err := nearby.NodesNearPod(context.Background(), client, "default", "nginx", nearby.NodeOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Node.Name != "node-a-2" || len(result.Nodes) != 2 {
+		t.Errorf("Expected the 2 nodes in the zone of node-a-2, got: %v", result.Nodes)
+	}
+
+	_, err = nearby.NodesNearPod(context.Background(), client, "default", "pending", nearby.NodeOptions{})
+	var unscheduled errs.ErrUnscheduled
+	if !errors.As(err, &unscheduled) {
+		t.Errorf("Expected error type: %T, got: %T (%v)", unscheduled, err, err)
+	}
+
+	_, err = nearby.NodesNearPod(context.Background(), client, "default", "missing", nearby.NodeOptions{})
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+	}
+}
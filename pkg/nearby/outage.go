@@ -0,0 +1,364 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// OutageOptions select the nodes of a simulated outage. Exactly one of Zone
+// and Node must be set.
+type OutageOptions struct {
+	Zone string
+	// Region limits the zone to the nodes of a region. It is required if
+	// the zone's name is used in several regions.
+	Region string
+	Node   string
+	// Namespace limits the workloads and PodDisruptionBudgets considered. It
+	// is empty for all namespaces.
+	Namespace string
+}
+
+// A Workload is the top level controller of a set of pods (e.g. a Deployment
+// rather than its ReplicaSet). Pods without a controller are their own
+// workload.
+type Workload struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// A WorkloadImpact is a workload with pods on the nodes of an outage.
+type WorkloadImpact struct {
+	Workload Workload
+	// Pods is the number of scheduled pods that haven't finished.
+	Pods int
+	// Lost is the number of those pods on the nodes of the outage.
+	Lost int
+}
+
+// A DisruptionBudgetImpact is a PodDisruptionBudget with pods on the nodes of
+// an outage.
+type DisruptionBudgetImpact struct {
+	Namespace string
+	Name      string
+	// Healthy is the number of matching pods that are ready now.
+	Healthy int
+	// Remaining is the number of those pods not on the nodes of the outage.
+	Remaining int
+	// DesiredHealthy is the fewest healthy pods the budget allows.
+	DesiredHealthy int
+}
+
+// Violated returns true if fewer pods than allowed would remain.
+func (impact DisruptionBudgetImpact) Violated() bool {
+	return impact.Remaining < impact.DesiredHealthy
+}
+
+// A QuorumImpact is a StatefulSet with pods on the nodes of an outage.
+type QuorumImpact struct {
+	Namespace string
+	Name      string
+	Replicas  int
+	// Remaining is the number of ready pods not on the nodes of the outage.
+	Remaining int
+}
+
+// Quorum returns the majority of the StatefulSet's replicas.
+func (impact QuorumImpact) Quorum() int {
+	return impact.Replicas/2 + 1
+}
+
+// Lost returns true if fewer than a majority of the replicas would remain.
+func (impact QuorumImpact) Lost() bool {
+	return impact.Remaining < impact.Quorum()
+}
+
+// An OutageResult is the impact of losing a set of nodes.
+type OutageResult struct {
+	// Nodes are the names of the nodes lost in the outage.
+	Nodes []string
+	// Workloads with pods on the nodes, sorted by namespace, kind and name.
+	// DaemonSets are left out since their pods only serve their own node.
+	Workloads []WorkloadImpact
+	// DisruptionBudgets matching pods on the nodes, sorted by namespace and
+	// name.
+	DisruptionBudgets []DisruptionBudgetImpact
+	// StatefulSets with pods on the nodes, sorted by namespace and name.
+	StatefulSets []QuorumImpact
+}
+
+// SimulateOutage computes, from the current nodes and pods, what would be
+// lost if every node in a zone, or a single node, went away.
+func SimulateOutage(ctx context.Context, client kubernetes.Interface, opts OutageOptions) (*OutageResult, error) {
+	if (opts.Zone == "") == (opts.Node == "") {
+		return nil, errs.ErrUsage{Err: fmt.Errorf("either a zone or a node is required")}
+	}
+	if opts.Region != "" && opts.Zone == "" {
+		return nil, errs.ErrUsage{Err: fmt.Errorf("a region can only be given with a zone")}
+	}
+
+	lost := map[string]bool{}
+	result := &OutageResult{}
+	if opts.Node != "" {
+		_, err := client.CoreV1().Nodes().Get(ctx, opts.Node, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch node: %w", errs.FromAPI(err, "node", "", opts.Node))
+		}
+		lost[opts.Node] = true
+		result.Nodes = []string{opts.Node}
+	} else {
+		nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch nodes: %w", errs.FromAPI(err, "nodes", "", ""))
+		}
+		zoneKeys, _ := topology.Keys(topology.LevelZone)
+		regionKeys, _ := topology.Keys(topology.LevelRegion)
+		regions := []string{}
+		for _, node := range nodes.Items {
+			zone, _, ok := topology.Value(node.Labels, zoneKeys)
+			if !ok || zone != opts.Zone {
+				continue
+			}
+			region, _, _ := topology.Value(node.Labels, regionKeys)
+			if opts.Region != "" && region != opts.Region {
+				continue
+			}
+			if region != "" && !contains(regions, region) {
+				regions = append(regions, region)
+			}
+			lost[node.Name] = true
+			result.Nodes = append(result.Nodes, node.Name)
+		}
+		if len(result.Nodes) == 0 {
+			return nil, errs.ErrNotFound{Kind: "nodes in zone", Name: opts.Zone}
+		}
+		// Zone names are only unique within a region (e.g. a zone named "a"
+		// in each region).
+		if len(regions) > 1 {
+			sort.Strings(regions)
+			return nil, errs.ErrUsage{Err: fmt.Errorf("zone %v is in several regions (%v), a region is required", opts.Zone, strings.Join(regions, ", "))}
+		}
+		sort.Strings(result.Nodes)
+	}
+
+	podList, err := client.CoreV1().Pods(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pods: %w", errs.FromAPI(err, "pods", opts.Namespace, ""))
+	}
+	replicaSets, err := client.AppsV1().ReplicaSets(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch replica sets: %w", errs.FromAPI(err, "replicasets", opts.Namespace, ""))
+	}
+	owners := replicaSetOwners(replicaSets.Items)
+	pods := []v1.Pod{}
+	for _, pod := range podList.Items {
+		if isActive(pod) {
+			pods = append(pods, pod)
+		}
+	}
+
+	workloads := map[Workload]*WorkloadImpact{}
+	affected := map[Workload]bool{}
+	for _, pod := range pods {
+		workload := WorkloadOf(pod, owners)
+		if workload.Kind == KindDaemonSet {
+			continue
+		}
+		impact, ok := workloads[workload]
+		if !ok {
+			impact = &WorkloadImpact{Workload: workload}
+			workloads[workload] = impact
+		}
+		impact.Pods++
+		if lost[pod.Spec.NodeName] {
+			impact.Lost++
+			affected[workload] = true
+		}
+	}
+	for workload := range affected {
+		result.Workloads = append(result.Workloads, *workloads[workload])
+	}
+	sort.Slice(result.Workloads, func(i, j int) bool {
+		a, b := result.Workloads[i].Workload, result.Workloads[j].Workload
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+
+	budgets, err := client.PolicyV1().PodDisruptionBudgets(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pod disruption budgets: %w", errs.FromAPI(err, "poddisruptionbudgets", opts.Namespace, ""))
+	}
+	for _, budget := range budgets.Items {
+		impact, ok, err := disruptionBudgetImpact(budget, pods, func(pod v1.Pod) bool {
+			return lost[pod.Spec.NodeName]
+		})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result.DisruptionBudgets = append(result.DisruptionBudgets, impact)
+		}
+	}
+	sort.Slice(result.DisruptionBudgets, func(i, j int) bool {
+		a, b := result.DisruptionBudgets[i], result.DisruptionBudgets[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	statefulSets, err := client.AppsV1().StatefulSets(opts.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch stateful sets: %w", errs.FromAPI(err, "statefulsets", opts.Namespace, ""))
+	}
+	for _, statefulSet := range statefulSets.Items {
+		if impact, ok := quorumImpact(statefulSet, pods, lost); ok {
+			result.StatefulSets = append(result.StatefulSets, impact)
+		}
+	}
+	sort.Slice(result.StatefulSets, func(i, j int) bool {
+		a, b := result.StatefulSets[i], result.StatefulSets[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return result, nil
+}
+
+// disruptionBudgetImpact returns the impact on the budget, or false if none
+// of its pods are lost.
+func disruptionBudgetImpact(budget policyv1.PodDisruptionBudget, pods []v1.Pod, lost func(pod v1.Pod) bool) (DisruptionBudgetImpact, bool, error) {
+	impact := DisruptionBudgetImpact{Namespace: budget.Namespace, Name: budget.Name}
+	if budget.Spec.Selector == nil {
+		return impact, false, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(budget.Spec.Selector)
+	if err != nil {
+		return impact, false, fmt.Errorf("invalid selector for pod disruption budget %v/%v: %v", budget.Namespace, budget.Name, err)
+	}
+
+	expected := 0
+	affected := false
+	for _, pod := range pods {
+		if pod.Namespace != budget.Namespace || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		expected++
+		if lost(pod) {
+			affected = true
+		}
+		if isReady(pod) {
+			impact.Healthy++
+			if !lost(pod) {
+				impact.Remaining++
+			}
+		}
+	}
+	if !affected {
+		return impact, false, nil
+	}
+
+	// Like the disruption controller, percentages are of the pods expected
+	// by the budget's controllers (e.g. including replicas that aren't
+	// scheduled) and round up. The matching pods are used until the
+	// controller has set the budget's status.
+	if budget.Status.ExpectedPods > 0 {
+		expected = int(budget.Status.ExpectedPods)
+	}
+	switch {
+	case budget.Spec.MinAvailable != nil:
+		impact.DesiredHealthy, err = intstr.GetScaledValueFromIntOrPercent(budget.Spec.MinAvailable, expected, true)
+	case budget.Spec.MaxUnavailable != nil:
+		var maxUnavailable int
+		maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(budget.Spec.MaxUnavailable, expected, true)
+		impact.DesiredHealthy = max(expected-maxUnavailable, 0)
+	}
+	if err != nil {
+		return impact, false, fmt.Errorf("invalid pod disruption budget %v/%v: %v", budget.Namespace, budget.Name, err)
+	}
+	return impact, true, nil
+}
+
+// quorumImpact returns the impact on the StatefulSet, or false if none of its
+// pods are lost.
+func quorumImpact(statefulSet appsv1.StatefulSet, pods []v1.Pod, lost map[string]bool) (QuorumImpact, bool) {
+	impact := QuorumImpact{Namespace: statefulSet.Namespace, Name: statefulSet.Name, Replicas: 1}
+	if statefulSet.Spec.Replicas != nil {
+		impact.Replicas = int(*statefulSet.Spec.Replicas)
+	}
+	affected := false
+	for _, pod := range pods {
+		if !isControlledBy(pod.ObjectMeta, statefulSet.UID) {
+			continue
+		}
+		if lost[pod.Spec.NodeName] {
+			affected = true
+		} else if isReady(pod) {
+			impact.Remaining++
+		}
+	}
+	return impact, affected
+}
+
+// WorkloadOf returns the top level controller of the pod, given the owners of
+// ReplicaSets (see replicaSetOwners).
+func WorkloadOf(pod v1.Pod, replicaSetOwners map[types.UID]Workload) Workload {
+	controller := metav1.GetControllerOf(&pod)
+	if controller == nil {
+		return Workload{Kind: KindPod, Namespace: pod.Namespace, Name: pod.Name}
+	}
+	if controller.Kind == KindReplicaSet {
+		if owner, ok := replicaSetOwners[controller.UID]; ok {
+			return owner
+		}
+	}
+	return Workload{Kind: controller.Kind, Namespace: pod.Namespace, Name: controller.Name}
+}
+
+// replicaSetOwners maps the UID of each ReplicaSet controlled by a Deployment
+// to the Deployment.
+func replicaSetOwners(replicaSets []appsv1.ReplicaSet) map[types.UID]Workload {
+	owners := map[types.UID]Workload{}
+	for _, replicaSet := range replicaSets {
+		controller := metav1.GetControllerOf(&replicaSet)
+		if controller != nil && controller.Kind == KindDeployment {
+			owners[replicaSet.UID] = Workload{Kind: KindDeployment, Namespace: replicaSet.Namespace, Name: controller.Name}
+		}
+	}
+	return owners
+}
+
+// isActive returns true if the pod is scheduled and hasn't finished.
+func isActive(pod v1.Pod) bool {
+	return pod.Spec.NodeName != "" && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed
+}
+
+// isReady returns true if the pod's Ready condition is true.
+func isReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
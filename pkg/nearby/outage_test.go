@@ -0,0 +1,226 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestSimulateOutage(t *testing.T) {
+	client := outageClient()
+
+	t.Run("with a zone, reports the impact of losing its nodes", func(t *testing.T) {
+		result, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Zone: "us-east4-a", Namespace: "default"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Nodes, []string{"node-a-1", "node-a-2"}) {
+			t.Errorf("Expected nodes: [node-a-1 node-a-2], got: %v", result.Nodes)
+		}
+		expectedWorkloads := []nearby.WorkloadImpact{
+			{Workload: nearby.Workload{Kind: "Deployment", Namespace: "default", Name: "api"}, Pods: 2, Lost: 2},
+			{Workload: nearby.Workload{Kind: "Deployment", Namespace: "default", Name: "web"}, Pods: 2, Lost: 1},
+			{Workload: nearby.Workload{Kind: "Pod", Namespace: "default", Name: "debug"}, Pods: 1, Lost: 1},
+			{Workload: nearby.Workload{Kind: "StatefulSet", Namespace: "default", Name: "etcd"}, Pods: 3, Lost: 2},
+		}
+		if !reflect.DeepEqual(result.Workloads, expectedWorkloads) {
+			t.Errorf("Expected workloads:\n%+v\ngot:\n%+v", expectedWorkloads, result.Workloads)
+		}
+		expectedBudgets := []nearby.DisruptionBudgetImpact{
+			{Namespace: "default", Name: "api", Healthy: 2, Remaining: 0, DesiredHealthy: 1},
+			{Namespace: "default", Name: "web", Healthy: 2, Remaining: 1, DesiredHealthy: 1},
+		}
+		if !reflect.DeepEqual(result.DisruptionBudgets, expectedBudgets) {
+			t.Errorf("Expected disruption budgets:\n%+v\ngot:\n%+v", expectedBudgets, result.DisruptionBudgets)
+		}
+		if !result.DisruptionBudgets[0].Violated() || result.DisruptionBudgets[1].Violated() {
+			t.Errorf("Expected only the api budget to be violated")
+		}
+		expectedStatefulSets := []nearby.QuorumImpact{{Namespace: "default", Name: "etcd", Replicas: 3, Remaining: 1}}
+		if !reflect.DeepEqual(result.StatefulSets, expectedStatefulSets) {
+			t.Errorf("Expected stateful sets:\n%+v\ngot:\n%+v", expectedStatefulSets, result.StatefulSets)
+		}
+		if result.StatefulSets[0].Quorum() != 2 || !result.StatefulSets[0].Lost() {
+			t.Errorf("Expected etcd to lose its quorum of 2")
+		}
+	})
+
+	t.Run("with a node, reports the impact of losing it", func(t *testing.T) {
+		result, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Node: "node-a-2"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectedWorkloads := []nearby.WorkloadImpact{
+			{Workload: nearby.Workload{Kind: "Deployment", Namespace: "default", Name: "api"}, Pods: 2, Lost: 1},
+			{Workload: nearby.Workload{Kind: "StatefulSet", Namespace: "default", Name: "etcd"}, Pods: 3, Lost: 1},
+		}
+		if !reflect.DeepEqual(result.Workloads, expectedWorkloads) {
+			t.Errorf("Expected workloads:\n%+v\ngot:\n%+v", expectedWorkloads, result.Workloads)
+		}
+		if len(result.DisruptionBudgets) != 1 || result.DisruptionBudgets[0].Violated() {
+			t.Errorf("Expected the api budget not to be violated, got: %+v", result.DisruptionBudgets)
+		}
+		if len(result.StatefulSets) != 1 || result.StatefulSets[0].Lost() {
+			t.Errorf("Expected etcd to keep its quorum, got: %+v", result.StatefulSets)
+		}
+	})
+
+	t.Run("with a percentage budget, scales it by the pods the budget expects", func(t *testing.T) {
+		// Two of the cache's four replicas are pending, so only half of
+		// them would remain rather than half of the scheduled ones.
+		budget := disruptionBudget("cache", intstr.FromString("50%"), nil)
+		budget.Status.ExpectedPods = 4
+		client := testclient.NewSimpleClientset(
+			testNode("node-a-1", nil),
+			testNode("node-b-1", nil),
+			readyPod("cache-abc-1", "node-a-1", "ReplicaSet", "cache-abc"),
+			readyPod("cache-abc-2", "node-b-1", "ReplicaSet", "cache-abc"),
+			budget,
+		)
+		result, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Node: "node-a-1", Namespace: "default"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expectedBudgets := []nearby.DisruptionBudgetImpact{
+			{Namespace: "default", Name: "cache", Healthy: 2, Remaining: 1, DesiredHealthy: 2},
+		}
+		if !reflect.DeepEqual(result.DisruptionBudgets, expectedBudgets) {
+			t.Errorf("Expected disruption budgets:\n%+v\ngot:\n%+v", expectedBudgets, result.DisruptionBudgets)
+		}
+		if !result.DisruptionBudgets[0].Violated() {
+			t.Errorf("Expected the cache budget to be violated")
+		}
+	})
+
+	t.Run("with an unknown zone, returns not found", func(t *testing.T) {
+		_, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Zone: "us-west1-a"})
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+
+	t.Run("with a zone named in several regions, returns a usage error unless a region is given", func(t *testing.T) {
+		labels := func(zone string, region string) map[string]string {
+			return map[string]string{"topology.kubernetes.io/zone": zone, "topology.kubernetes.io/region": region}
+		}
+		client := testclient.NewSimpleClientset(
+			testNode("node-east-1", labels("a", "east")),
+			testNode("node-west-1", labels("a", "west")),
+			testNode("node-west-2", labels("b", "west")),
+			readyPod("debug", "node-west-1", "", ""),
+		)
+		_, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Zone: "a", Namespace: "default"})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+
+		result, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Zone: "a", Region: "west", Namespace: "default"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result.Nodes, []string{"node-west-1"}) {
+			t.Errorf("Expected nodes: [node-west-1], got: %v", result.Nodes)
+		}
+	})
+
+	t.Run("with a region and no zone, returns a usage error", func(t *testing.T) {
+		_, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Region: "us-east4", Node: "node-a-1"})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+
+	t.Run("with both a zone and a node, returns a usage error", func(t *testing.T) {
+		_, err := nearby.SimulateOutage(context.Background(), client, nearby.OutageOptions{Zone: "us-east4-a", Node: "node-a-1"})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+// outageClient returns a fake client with two zones. The Deployment api and
+// most of the StatefulSet etcd are in zone us-east4-a, the Deployment web is
+// spread across both zones.
+func outageClient() kubernetes.Interface {
+	replicas := int32(3)
+	zone := func(zone string) map[string]string {
+		return map[string]string{"topology.kubernetes.io/zone": zone}
+	}
+	return testclient.NewSimpleClientset(
+		testNode("node-a-1", zone("us-east4-a")),
+		testNode("node-a-2", zone("us-east4-a")),
+		testNode("node-b-1", zone("us-east4-b")),
+		ownedReplicaSet("api-abc", "api"),
+		ownedReplicaSet("web-abc", "web"),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd", Namespace: "default", UID: "etcd"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		},
+		readyPod("api-abc-1", "node-a-1", "ReplicaSet", "api-abc"),
+		readyPod("api-abc-2", "node-a-2", "ReplicaSet", "api-abc"),
+		readyPod("web-abc-1", "node-a-1", "ReplicaSet", "web-abc"),
+		readyPod("web-abc-2", "node-b-1", "ReplicaSet", "web-abc"),
+		readyPod("etcd-0", "node-a-1", "StatefulSet", "etcd"),
+		readyPod("etcd-1", "node-a-2", "StatefulSet", "etcd"),
+		readyPod("etcd-2", "node-b-1", "StatefulSet", "etcd"),
+		readyPod("agent-a-1", "node-a-1", "DaemonSet", "agent"),
+		readyPod("debug", "node-a-1", "", ""),
+		disruptionBudget("api", intstr.FromInt32(1), nil),
+		disruptionBudget("web", intstr.IntOrString{}, &intstr.IntOrString{Type: intstr.String, StrVal: "50%"}),
+	)
+}
+
+func ownedReplicaSet(name string, deployment string) *appsv1.ReplicaSet {
+	controller := true
+	return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       "default",
+		UID:             types.UID(name),
+		OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: deployment, UID: types.UID(deployment), Controller: &controller}},
+	}}
+}
+
+// readyPod returns a running and ready pod, controlled by the given owner
+// unless kind is empty, and labeled with the owner's name (app=NAME).
+func readyPod(name string, nodeName string, kind string, owner string) *v1.Pod {
+	pod := nodePod("default", name, nodeName)
+	pod.Status.Phase = v1.PodRunning
+	pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	if kind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, UID: types.UID(owner), Controller: &controller}}
+		pod.Labels = map[string]string{"app": owner}
+	}
+	return pod
+}
+
+// disruptionBudget returns a budget for the pods of the ReplicaSet NAME-abc,
+// with maxUnavailable if it's given and otherwise minAvailable.
+func disruptionBudget(name string, minAvailable intstr.IntOrString, maxUnavailable *intstr.IntOrString) *policyv1.PodDisruptionBudget {
+	budget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name + "-abc"}},
+		},
+	}
+	if maxUnavailable != nil {
+		budget.Spec.MaxUnavailable = maxUnavailable
+	} else {
+		budget.Spec.MinAvailable = &minAvailable
+	}
+	return budget
+}
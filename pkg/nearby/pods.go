@@ -0,0 +1,289 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// PodOptions configure how nearby pods are found.
+type PodOptions struct {
+	// Topology is how far "nearby" reaches: topology.LevelNode (the default),
+	// topology.LevelZone, topology.LevelRegion or a node label key.
+	Topology string
+	// AllNamespaces includes neighbors from every namespace instead of only
+	// the targets' namespaces.
+	AllNamespaces bool
+}
+
+// A Target is a pod whose neighbors are found.
+type Target struct {
+	Pod v1.Pod
+	// Nodes are the names of the nodes considered near the target. For an
+	// unscheduled target, these are the nodes it could be scheduled on.
+	Nodes []string
+	// Unscheduled is true if the target has no node yet.
+	Unscheduled bool
+}
+
+// A Neighbor is a pod near one or more targets.
+type Neighbor struct {
+	Pod v1.Pod
+	// Near lists the names of the targets the pod is near.
+	Near []string
+}
+
+// PodsResult holds the pods near a set of targets.
+type PodsResult struct {
+	Targets []Target
+	// Neighbors are sorted by node, namespace and name. Targets are included
+	// as neighbors of themselves.
+	Neighbors []Neighbor
+	// TopologyKeys are the node label keys used to find nearby nodes. It is
+	// empty for the node topology level.
+	TopologyKeys []string
+	// TopologyValues are the sorted, distinct values of the topology label
+	// on the targets' nodes.
+	TopologyValues []string
+	// Nodes are the sorted names of the nodes the neighbors were listed from.
+	Nodes []string
+	// Namespaces are the namespaces the neighbors were listed from
+	// (metav1.NamespaceAll for all namespaces).
+	Namespaces []string
+}
+
+// PodsNearPod returns the pods near the given pod.
+func PodsNearPod(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts PodOptions) (*PodsResult, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errs.FromAPI(err, "pod", namespace, name)
+	}
+	return PodsNearPods(ctx, client, []v1.Pod{*pod}, opts)
+}
+
+// PodsNearNode returns the pods in the namespace near the given node, e.g.
+// the pods on the node itself for the node topology level. The result has no
+// targets.
+func PodsNearNode(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts PodOptions) (*PodsResult, error) {
+	level := opts.Topology
+	if level == "" {
+		level = topology.LevelNode
+	}
+	keys, err := topology.Keys(level)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PodsResult{Namespaces: []string{namespace}}
+	if opts.AllNamespaces {
+		result.Namespaces = []string{metav1.NamespaceAll}
+	}
+	if level == topology.LevelNode {
+		_, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch node: %w", errs.FromAPI(err, "node", "", name))
+		}
+		result.Nodes = []string{name}
+	} else {
+		value, nearbyNodes, err := nodesSharingTopology(ctx, client, &nodeCache{client: client}, name, keys)
+		if err != nil {
+			return nil, err
+		}
+		result.TopologyKeys = keys
+		result.TopologyValues = []string{value}
+		result.Nodes = nodeNamesOf(nearbyNodes)
+	}
+
+	err = result.listNeighbors(ctx, client, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// PodsNearSelector returns the pods near every pod in the namespace matching
+// the label selector.
+func PodsNearSelector(ctx context.Context, client kubernetes.Interface, namespace string, selector string, opts PodOptions) (*PodsResult, error) {
+	targets, err := SelectorPods(ctx, client, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	return PodsNearPods(ctx, client, targets, opts)
+}
+
+// PodsNearWorkload returns the pods near every pod of the given workload
+// (e.g. KindDeployment).
+func PodsNearWorkload(ctx context.Context, client kubernetes.Interface, namespace string, kind string, name string, opts PodOptions) (*PodsResult, error) {
+	targets, err := WorkloadPods(ctx, client, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return PodsNearPods(ctx, client, targets, opts)
+}
+
+// SelectorPods returns the pods in the namespace matching the label selector.
+func SelectorPods(ctx context.Context, client kubernetes.Interface, namespace string, selector string) ([]v1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pods matching selector %v: %w", selector, errs.FromAPI(err, "pods", namespace, ""))
+	}
+	if len(pods.Items) == 0 {
+		return nil, errs.ErrNotFound{Kind: "pods", Namespace: namespace, Selector: selector}
+	}
+	return pods.Items, nil
+}
+
+// PodsNearPods returns the pods near the given target pods. Unscheduled
+// targets (e.g. status: Pending) have no node yet, so the nodes they could be
+// scheduled on are used instead. An errs.ErrUnscheduled is returned if no
+// target has a node or a candidate node.
+func PodsNearPods(ctx context.Context, client kubernetes.Interface, targets []v1.Pod, opts PodOptions) (*PodsResult, error) {
+	level := opts.Topology
+	if level == "" {
+		level = topology.LevelNode
+	}
+	keys, err := topology.Keys(level)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PodsResult{}
+	if level != topology.LevelNode {
+		result.TopologyKeys = keys
+	}
+	nodes := &nodeCache{client: client}
+	nodeNames := []string{}
+	nodeTargets := map[string][]string{}
+	namespaces := []string{}
+	values := map[string]bool{}
+	for _, pod := range targets {
+		target := Target{Pod: pod}
+		switch {
+		case pod.Spec.NodeName == "":
+			// Listing pods with an empty spec.nodeName would return every
+			// other unscheduled pod.
+			allNodes, err := nodes.list(ctx)
+			if err != nil {
+				return nil, err
+			}
+			target.Unscheduled = true
+			target.Nodes = nodeNamesOf(CandidateNodes(&pod, allNodes))
+		case level == topology.LevelNode:
+			target.Nodes = []string{pod.Spec.NodeName}
+		default:
+			value, nearbyNodes, err := nodesSharingTopology(ctx, client, nodes, pod.Spec.NodeName, keys)
+			if err != nil {
+				return nil, err
+			}
+			values[value] = true
+			target.Nodes = nodeNamesOf(nearbyNodes)
+		}
+
+		for _, nodeName := range target.Nodes {
+			if _, ok := nodeTargets[nodeName]; !ok {
+				nodeNames = append(nodeNames, nodeName)
+			}
+			nodeTargets[nodeName] = append(nodeTargets[nodeName], pod.Name)
+		}
+		if !contains(namespaces, pod.Namespace) {
+			namespaces = append(namespaces, pod.Namespace)
+		}
+		result.Targets = append(result.Targets, target)
+	}
+	for value := range values {
+		result.TopologyValues = append(result.TopologyValues, value)
+	}
+	sort.Strings(result.TopologyValues)
+	sort.Strings(nodeNames)
+
+	if len(nodeNames) == 0 {
+		for _, target := range result.Targets {
+			if target.Unscheduled {
+				return nil, errs.ErrUnscheduled{
+					Namespace:         target.Pod.Namespace,
+					Name:              target.Pod.Name,
+					NominatedNodeName: target.Pod.Status.NominatedNodeName,
+				}
+			}
+		}
+	}
+
+	if opts.AllNamespaces {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	result.Nodes = nodeNames
+	result.Namespaces = namespaces
+	err = result.listNeighbors(ctx, client, nodeTargets)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// listNeighbors lists the pods on the result's nodes as its neighbors, near
+// the targets on each node.
+func (result *PodsResult) listNeighbors(ctx context.Context, client kubernetes.Interface, nodeTargets map[string][]string) error {
+	for _, nodeName := range result.Nodes {
+		for _, namespace := range result.Namespaces {
+			pods, err := PodsOnNode(ctx, client, namespace, nodeName)
+			if err != nil {
+				return err
+			}
+			for _, pod := range pods {
+				result.Neighbors = append(result.Neighbors, Neighbor{
+					Pod:  pod,
+					Near: nodeTargets[nodeName],
+				})
+			}
+		}
+	}
+	sort.SliceStable(result.Neighbors, func(i, j int) bool {
+		a, b := result.Neighbors[i].Pod, result.Neighbors[j].Pod
+		if a.Spec.NodeName != b.Spec.NodeName {
+			return a.Spec.NodeName < b.Spec.NodeName
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return nil
+}
+
+// PodsOnNode returns the pods in the namespace (or all namespaces if empty)
+// scheduled on the given node.
+func PodsOnNode(ctx context.Context, client kubernetes.Interface, namespace string, nodeName string) ([]v1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("spec.nodeName=%v", nodeName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pods on node %v: %w", nodeName, errs.FromAPI(err, "pods", namespace, ""))
+	}
+	podsOnNode := []v1.Pod{}
+	for _, pod := range pods.Items {
+		// Not every client honors field selectors (e.g. the fake
+		// clientset), so check the node here as well.
+		if pod.Spec.NodeName == nodeName {
+			podsOnNode = append(podsOnNode, pod)
+		}
+	}
+	return podsOnNode, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
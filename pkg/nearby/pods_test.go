@@ -0,0 +1,191 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+func TestPodsNearPod(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+		nodePod("default", "nginx", "node-a-1"),
+		nodePod("default", "redis", "node-a-1"),
+		nodePod("kube-system", "fluentd", "node-a-1"),
+		nodePod("default", "web", "node-a-2"),
+		nodePod("default", "api", "node-b-1"),
+		nodePod("default", "pending", ""),
+	)
+
+	var testCases = []struct {
+		name      string
+		pod       string
+		opts      nearby.PodOptions
+		neighbors []string
+		nodes     []string
+		values    []string
+	}{
+		{
+			"defaults to the pod's node",
+			"nginx",
+			nearby.PodOptions{},
+			[]string{"default/nginx", "default/redis"},
+			[]string{"node-a-1"},
+			nil,
+		},
+		{
+			"with all namespaces",
+			"nginx",
+			nearby.PodOptions{AllNamespaces: true},
+			[]string{"default/nginx", "default/redis", "kube-system/fluentd"},
+			[]string{"node-a-1"},
+			nil,
+		},
+		{
+			"with the zone topology",
+			"nginx",
+			nearby.PodOptions{Topology: topology.LevelZone},
+			[]string{"default/nginx", "default/redis", "default/web"},
+			[]string{"node-a-1", "node-a-2"},
+			[]string{"us-east4-a"},
+		},
+		{
+			"with an unscheduled pod, uses the nodes it could be scheduled on",
+			"pending",
+			nearby.PodOptions{},
+			[]string{"default/nginx", "default/redis", "default/web", "default/api"},
+			[]string{"node-a-1", "node-a-2", "node-b-1"},
+			nil,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := nearby.PodsNearPod(context.Background(), client, "default", testCase.pod, testCase.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			neighbors := []string{}
+			for _, neighbor := range result.Neighbors {
+				neighbors = append(neighbors, neighbor.Pod.Namespace+"/"+neighbor.Pod.Name)
+				if !reflect.DeepEqual(neighbor.Near, []string{testCase.pod}) {
+					t.Errorf("Expected %v to be near: %v, got: %v", neighbor.Pod.Name, testCase.pod, neighbor.Near)
+				}
+			}
+			if !reflect.DeepEqual(testCase.neighbors, neighbors) {
+				t.Errorf("Expected neighbors: %v, got: %v", testCase.neighbors, neighbors)
+			}
+			if len(result.Targets) != 1 || !reflect.DeepEqual(testCase.nodes, result.Targets[0].Nodes) {
+				t.Errorf("Expected target nodes: %v, got: %+v", testCase.nodes, result.Targets)
+			}
+			if !reflect.DeepEqual(testCase.values, result.TopologyValues) {
+				t.Errorf("Expected topology values: %v, got: %v", testCase.values, result.TopologyValues)
+			}
+		})
+	}
+
+	t.Run("with an unknown pod, returns a not found error", func(t *testing.T) {
+		_, err := nearby.PodsNearPod(context.Background(), client, "default", "missing", nearby.PodOptions{})
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+}
+
+func TestPodsNearNode(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+		nodePod("default", "nginx", "node-a-1"),
+		nodePod("kube-system", "fluentd", "node-a-1"),
+		nodePod("default", "web", "node-a-2"),
+		nodePod("default", "api", "node-b-1"),
+	)
+
+	var testCases = []struct {
+		name      string
+		opts      nearby.PodOptions
+		neighbors []string
+		nodes     []string
+	}{
+		{"defaults to the node", nearby.PodOptions{}, []string{"default/nginx"}, []string{"node-a-1"}},
+		{"with all namespaces", nearby.PodOptions{AllNamespaces: true}, []string{"default/nginx", "kube-system/fluentd"}, []string{"node-a-1"}},
+		{"with the zone topology", nearby.PodOptions{Topology: topology.LevelZone}, []string{"default/nginx", "default/web"}, []string{"node-a-1", "node-a-2"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := nearby.PodsNearNode(context.Background(), client, "default", "node-a-1", testCase.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			neighbors := []string{}
+			for _, neighbor := range result.Neighbors {
+				neighbors = append(neighbors, neighbor.Pod.Namespace+"/"+neighbor.Pod.Name)
+			}
+			if !reflect.DeepEqual(testCase.neighbors, neighbors) {
+				t.Errorf("Expected neighbors: %v, got: %v", testCase.neighbors, neighbors)
+			}
+			if !reflect.DeepEqual(testCase.nodes, result.Nodes) {
+				t.Errorf("Expected nodes: %v, got: %v", testCase.nodes, result.Nodes)
+			}
+		})
+	}
+
+	t.Run("with an unknown node, returns a not found error", func(t *testing.T) {
+		_, err := nearby.PodsNearNode(context.Background(), client, "default", "missing", nearby.PodOptions{})
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+}
+
+func TestPodsNearSelector(t *testing.T) {
+	web1 := nodePod("default", "web-1", "node-a-1")
+	web1.Labels = map[string]string{"app": "web"}
+	web2 := nodePod("default", "web-2", "node-b-1")
+	web2.Labels = map[string]string{"app": "web"}
+	client := testclient.NewSimpleClientset(web1, web2, nodePod("default", "api", "node-b-1"))
+
+	result, err := nearby.PodsNearSelector(context.Background(), client, "default", "app=web", nearby.PodOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got := map[string][]string{}
+	for _, neighbor := range result.Neighbors {
+		got[neighbor.Pod.Name] = neighbor.Near
+	}
+	expected := map[string][]string{"web-1": {"web-1"}, "api": {"web-2"}, "web-2": {"web-2"}}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected neighbors: %v, got: %v", expected, got)
+	}
+
+	_, err = nearby.PodsNearSelector(context.Background(), client, "default", "app=none", nearby.PodOptions{})
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+	}
+}
+
+func nodePod(namespace string, name string, nodeName string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func testNode(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
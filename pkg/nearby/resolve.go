@@ -0,0 +1,155 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// PodByIP returns the pod with the given IP, from status.podIP or
+// status.podIPs, searching every namespace since IPs are unique in a cluster.
+// Finished pods are ignored since their IPs may have been reused.
+func PodByIP(ctx context.Context, client kubernetes.Interface, ip string) (*v1.Pod, error) {
+	// status.podIP is indexed by the API server, so most lookups don't need
+	// to list every pod. Secondary IPs (e.g. dual-stack) are only in podIPs,
+	// which can't be selected, so they are searched in every pod.
+	pods, err := listPods(ctx, client, fmt.Sprintf("status.podIP=%v", ip))
+	if err != nil {
+		return nil, err
+	}
+	matches := podsMatching(pods, func(pod v1.Pod) bool { return pod.Status.PodIP == ip })
+	if len(matches) == 0 {
+		pods, err = listPods(ctx, client, "")
+		if err != nil {
+			return nil, err
+		}
+		matches = podsMatching(pods, func(pod v1.Pod) bool { return hasSecondaryPodIP(pod, ip) })
+	}
+	return onlyPod(matches, "pod with IP", ip)
+}
+
+// PodByContainerID returns the pod with a container with the given ID,
+// searching every namespace. The runtime prefix (e.g. containerd://) is
+// optional and the ID may be shortened, like the IDs shown by crictl or
+// docker.
+func PodByContainerID(ctx context.Context, client kubernetes.Interface, id string) (*v1.Pod, error) {
+	runtime, shortID, hasRuntime := strings.Cut(id, "://")
+	if !hasRuntime {
+		shortID = id
+	}
+	if shortID == "" {
+		return nil, errs.ErrUsage{Err: fmt.Errorf("invalid container ID: %q", id)}
+	}
+	pods, err := listPods(ctx, client, "")
+	if err != nil {
+		return nil, err
+	}
+	matches := podsMatching(pods, func(pod v1.Pod) bool {
+		statuses := [][]v1.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses, pod.Status.EphemeralContainerStatuses}
+		for _, status := range slices.Concat(statuses...) {
+			statusRuntime, statusID, _ := strings.Cut(status.ContainerID, "://")
+			if hasRuntime && statusRuntime != runtime {
+				continue
+			}
+			if statusID != "" && strings.HasPrefix(statusID, shortID) {
+				return true
+			}
+		}
+		return false
+	})
+	return onlyPod(matches, "pod with container ID", id)
+}
+
+// NodeByIP returns the node with the given address (e.g. its internal IP).
+func NodeByIP(ctx context.Context, client kubernetes.Interface, ip string) (*v1.Node, error) {
+	return findNode(ctx, client, "node with IP", ip, func(node v1.Node) bool {
+		for _, address := range node.Status.Addresses {
+			if address.Address == ip {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NodeByProviderID returns the node with the given spec.providerID (e.g.
+// aws:///us-east-1a/i-0123).
+func NodeByProviderID(ctx context.Context, client kubernetes.Interface, providerID string) (*v1.Node, error) {
+	return findNode(ctx, client, "node with provider ID", providerID, func(node v1.Node) bool {
+		return node.Spec.ProviderID == providerID
+	})
+}
+
+func findNode(ctx context.Context, client kubernetes.Interface, kind string, value string, matches func(v1.Node) bool) (*v1.Node, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch nodes: %w", errs.FromAPI(err, "nodes", "", ""))
+	}
+	found := []v1.Node{}
+	for _, node := range nodes.Items {
+		if matches(node) {
+			found = append(found, node)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, errs.ErrNotFound{Kind: kind, Name: value}
+	case 1:
+		return &found[0], nil
+	}
+	return nil, fmt.Errorf("%v %q is ambiguous, it matches: %v", kind, value, strings.Join(nodeNamesOf(found), ", "))
+}
+
+// listPods lists the pods in every namespace matching the field selector.
+func listPods(ctx context.Context, client kubernetes.Interface, fieldSelector string) ([]v1.Pod, error) {
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pods: %w", errs.FromAPI(err, "pods", "", ""))
+	}
+	return pods.Items, nil
+}
+
+// podsMatching returns the pods that haven't finished and match.
+func podsMatching(pods []v1.Pod, matches func(v1.Pod) bool) []v1.Pod {
+	found := []v1.Pod{}
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed && matches(pod) {
+			found = append(found, pod)
+		}
+	}
+	return found
+}
+
+// onlyPod returns the single pod found, or an error if there are none or
+// several (e.g. pods using the host network share their node's IP).
+func onlyPod(pods []v1.Pod, kind string, value string) (*v1.Pod, error) {
+	switch len(pods) {
+	case 0:
+		return nil, errs.ErrNotFound{Kind: kind, Name: value}
+	case 1:
+		return &pods[0], nil
+	}
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return nil, fmt.Errorf("%v %q is ambiguous, it matches: %v", kind, value, strings.Join(names, ", "))
+}
+
+// hasSecondaryPodIP returns true if the IP is one of the pod's IPs other
+// than status.podIP.
+func hasSecondaryPodIP(pod v1.Pod, ip string) bool {
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP == ip && podIP.IP != pod.Status.PodIP {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestPodByIP(t *testing.T) {
+	nginx := nodePod("default", "nginx", "node-a-1")
+	nginx.Status.PodIP = "10.2.3.4"
+	nginx.Status.PodIPs = []v1.PodIP{{IP: "10.2.3.4"}, {IP: "fd00::4"}}
+	finished := nodePod("default", "job-1", "node-a-1")
+	finished.Status.PodIP = "10.2.3.5"
+	finished.Status.Phase = v1.PodSucceeded
+	agent1 := nodePod("kube-system", "agent-1", "node-a-1")
+	agent1.Status.PodIP = "192.168.0.1"
+	agent2 := nodePod("kube-system", "agent-2", "node-a-1")
+	agent2.Status.PodIP = "192.168.0.1"
+	client := testclient.NewSimpleClientset(nginx, finished, agent1, agent2)
+
+	// The primary IP is found with a field selector, while secondary IPs
+	// need every pod to be listed.
+	var testCases = []struct {
+		ip        string
+		selectors []string
+	}{
+		{"10.2.3.4", []string{"status.podIP=10.2.3.4"}},
+		{"fd00::4", []string{"status.podIP=fd00::4", ""}},
+	}
+	for _, testCase := range testCases {
+		client.ClearActions()
+		pod, err := nearby.PodByIP(context.Background(), client, testCase.ip)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", testCase.ip, err)
+		}
+		if pod.Name != "nginx" {
+			t.Errorf("Expected pod nginx for %v, got: %v", testCase.ip, pod.Name)
+		}
+		selectors := []string{}
+		for _, action := range client.Actions() {
+			if list, ok := action.(clienttesting.ListAction); ok {
+				selectors = append(selectors, list.GetListRestrictions().Fields.String())
+			}
+		}
+		if !reflect.DeepEqual(testCase.selectors, selectors) {
+			t.Errorf("Expected pod lists for %v with field selectors: %q, got: %q", testCase.ip, testCase.selectors, selectors)
+		}
+	}
+
+	_, err := nearby.PodByIP(context.Background(), client, "10.2.3.5")
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type for a finished pod: %T, got: %T (%v)", notFound, err, err)
+	}
+
+	_, err = nearby.PodByIP(context.Background(), client, "192.168.0.1")
+	if err == nil || errors.As(err, &notFound) {
+		t.Errorf("Expected an ambiguous IP error, got: %v", err)
+	}
+}
+
+func TestPodByContainerID(t *testing.T) {
+	nginx := nodePod("default", "nginx", "node-a-1")
+	nginx.Status.InitContainerStatuses = []v1.ContainerStatus{{ContainerID: "containerd://0123abcd"}}
+	nginx.Status.ContainerStatuses = []v1.ContainerStatus{{ContainerID: "containerd://4567cdef"}}
+	redis := nodePod("default", "redis", "node-a-1")
+	redis.Status.ContainerStatuses = []v1.ContainerStatus{{ContainerID: "docker://89abef01"}}
+	client := testclient.NewSimpleClientset(nginx, redis)
+
+	var testCases = []struct {
+		id       string
+		expected string
+	}{
+		{"containerd://4567cdef", "nginx"},
+		{"4567cdef", "nginx"},
+		{"0123", "nginx"},
+		{"docker://89ab", "redis"},
+	}
+	for _, testCase := range testCases {
+		pod, err := nearby.PodByContainerID(context.Background(), client, testCase.id)
+		if err != nil {
+			t.Fatalf("Unexpected error for %v: %v", testCase.id, err)
+		}
+		if pod.Name != testCase.expected {
+			t.Errorf("Expected pod %v for %v, got: %v", testCase.expected, testCase.id, pod.Name)
+		}
+	}
+
+	_, err := nearby.PodByContainerID(context.Background(), client, "containerd://89ab")
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type for another runtime: %T, got: %T (%v)", notFound, err, err)
+	}
+}
+
+func TestNodeByIPAndProviderID(t *testing.T) {
+	node := testNode("node-a-1", nil)
+	node.Spec.ProviderID = "aws:///us-east-1a/i-0123"
+	node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}, {Type: v1.NodeExternalIP, Address: "34.1.2.3"}}
+	client := testclient.NewSimpleClientset(node, testNode("node-a-2", nil))
+
+	found, err := nearby.NodeByProviderID(context.Background(), client, "aws:///us-east-1a/i-0123")
+	if err != nil || found.Name != "node-a-1" {
+		t.Errorf("Expected node-a-1 by provider ID, got: %v (%v)", found, err)
+	}
+	found, err = nearby.NodeByIP(context.Background(), client, "34.1.2.3")
+	if err != nil || found.Name != "node-a-1" {
+		t.Errorf("Expected node-a-1 by IP, got: %v (%v)", found, err)
+	}
+	_, err = nearby.NodeByIP(context.Background(), client, "10.0.0.9")
+	var notFound errs.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+	}
+}
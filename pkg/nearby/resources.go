@@ -0,0 +1,94 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	resourcehelper "k8s.io/component-helpers/resource"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// ResourceNames are the resources summed by NodeAllocation, in the order
+// they are shown.
+var ResourceNames = []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory, v1.ResourceEphemeralStorage}
+
+// PodResources are the effective requests and limits of a pod, including
+// init containers and overhead, as computed by the scheduler and kubectl
+// describe node.
+type PodResources struct {
+	Requests v1.ResourceList
+	Limits   v1.ResourceList
+}
+
+// ResourcesOf returns the effective requests and limits of the pod.
+func ResourcesOf(pod *v1.Pod) PodResources {
+	return PodResources{
+		Requests: resourcehelper.PodRequests(pod, resourcehelper.PodResourcesOptions{}),
+		Limits:   resourcehelper.PodLimits(pod, resourcehelper.PodResourcesOptions{}),
+	}
+}
+
+// A NodeAllocationResult holds the resources allocated on a node, like the
+// "Allocated resources" section of kubectl describe node.
+type NodeAllocationResult struct {
+	Node        string
+	Allocatable v1.ResourceList
+	// Requests and Limits are the sums over the pods on the node, in every
+	// namespace, that haven't finished.
+	Requests v1.ResourceList
+	Limits   v1.ResourceList
+	// Pods is the number of pods summed.
+	Pods int
+}
+
+// Share returns the quantity as a percentage of the node's allocatable
+// amount of the resource, or 0 if the node has none.
+func (result NodeAllocationResult) Share(name v1.ResourceName, quantity resource.Quantity) int64 {
+	allocatable, ok := result.Allocatable[name]
+	if !ok || allocatable.IsZero() {
+		return 0
+	}
+	return int64(float64(quantity.MilliValue()) / float64(allocatable.MilliValue()) * 100)
+}
+
+// NodeAllocation sums the requests and limits of the pods on the node.
+func NodeAllocation(ctx context.Context, client kubernetes.Interface, nodeName string) (*NodeAllocationResult, error) {
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch node: %w", errs.FromAPI(err, "node", "", nodeName))
+	}
+	pods, err := PodsOnNode(ctx, client, metav1.NamespaceAll, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &NodeAllocationResult{
+		Node:        nodeName,
+		Allocatable: node.Status.Allocatable,
+		Requests:    v1.ResourceList{},
+		Limits:      v1.ResourceList{},
+	}
+	for _, pod := range pods {
+		if !isActive(pod) {
+			continue
+		}
+		result.Pods++
+		resources := ResourcesOf(&pod)
+		addResources(result.Requests, resources.Requests)
+		addResources(result.Limits, resources.Limits)
+	}
+	return result, nil
+}
+
+func addResources(total v1.ResourceList, list v1.ResourceList) {
+	for name, quantity := range list {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
@@ -0,0 +1,54 @@
+package nearby_test
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestNodeAllocation(t *testing.T) {
+	node := testNode("node-a-1", nil)
+	node.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("8Gi")}
+	web := nodePod("default", "web", "node-a-1")
+	web.Spec.InitContainers = []v1.Container{{Name: "init", Resources: v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}}}
+	web.Spec.Containers = []v1.Container{{Name: "main", Resources: v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m"), v1.ResourceMemory: resource.MustParse("1Gi")},
+		Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+	}}}
+	agent := nodePod("kube-system", "agent", "node-a-1")
+	agent.Spec.Containers = []v1.Container{{Name: "main", Resources: v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+	}}}
+	finished := agent.DeepCopy()
+	finished.Name = "job"
+	finished.Status.Phase = v1.PodSucceeded
+	client := testclient.NewSimpleClientset(node, web, agent, finished, nodePod("default", "other", "node-b-1"))
+
+	result, err := nearby.NodeAllocation(context.Background(), client, "node-a-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Pods != 2 {
+		t.Errorf("Expected 2 pods, got: %v", result.Pods)
+	}
+	// The init container's request is the larger one for web.
+	cpu := result.Requests[v1.ResourceCPU]
+	if cpu.String() != "1500m" || result.Share(v1.ResourceCPU, cpu) != 37 {
+		t.Errorf("Expected CPU requests: 1500m (37%%), got: %v (%v%%)", cpu.String(), result.Share(v1.ResourceCPU, cpu))
+	}
+	memory := result.Limits[v1.ResourceMemory]
+	if memory.String() != "2Gi" || result.Share(v1.ResourceMemory, memory) != 25 {
+		t.Errorf("Expected memory limits: 2Gi (25%%), got: %v (%v%%)", memory.String(), result.Share(v1.ResourceMemory, memory))
+	}
+	storage := result.Requests[v1.ResourceEphemeralStorage]
+	if result.Share(v1.ResourceEphemeralStorage, storage) != 0 {
+		t.Errorf("Expected no share of a resource the node doesn't report")
+	}
+}
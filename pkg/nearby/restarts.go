@@ -0,0 +1,120 @@
+package nearby
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// A Termination is the last termination of a container, from the
+// lastState.terminated of its status.
+type Termination struct {
+	Namespace string
+	Pod       string
+	Container string
+	Reason    string
+	ExitCode  int32
+	// FinishedAt is when the container terminated.
+	FinishedAt time.Time
+	// RestartCount is the number of times the container has restarted.
+	RestartCount int32
+}
+
+// OOMKilled returns true if the container was killed for using more memory
+// than its limit, or than the node had left.
+func (termination Termination) OOMKilled() bool {
+	return termination.Reason == "OOMKilled"
+}
+
+// RestartsResult holds the last terminations of the containers co-located
+// with a pod.
+type RestartsResult struct {
+	Target v1.Pod
+	Node   string
+	// Terminations are sorted by FinishedAt. The target's own terminations
+	// are included.
+	Terminations []Termination
+}
+
+// RestartsNearPod returns the last terminations of the containers of the pods
+// on the given pod's node, in the pod's namespace or in all namespaces if
+// opts.AllNamespaces is true. opts.Topology is ignored: only pods on the
+// same node share its memory and CPU. An errs.ErrUnscheduled is returned if
+// the pod has no node.
+func RestartsNearPod(ctx context.Context, client kubernetes.Interface, namespace string, name string, opts PodOptions) (*RestartsResult, error) {
+	target, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errs.FromAPI(err, "pod", namespace, name)
+	}
+	if target.Spec.NodeName == "" {
+		return nil, errs.ErrUnscheduled{Namespace: namespace, Name: name, Reason: "has no co-located pods"}
+	}
+
+	listNamespace := namespace
+	if opts.AllNamespaces {
+		listNamespace = metav1.NamespaceAll
+	}
+	pods, err := PodsOnNode(ctx, client, listNamespace, target.Spec.NodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RestartsResult{Target: *target, Node: target.Spec.NodeName}
+	for _, pod := range pods {
+		result.Terminations = append(result.Terminations, TerminationsOf(pod)...)
+	}
+	sort.SliceStable(result.Terminations, func(i, j int) bool {
+		a, b := result.Terminations[i], result.Terminations[j]
+		if !a.FinishedAt.Equal(b.FinishedAt) {
+			return a.FinishedAt.Before(b.FinishedAt)
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pod < b.Pod
+	})
+	return result, nil
+}
+
+// TerminationsOf returns the last termination of each container of the pod
+// (including init containers) that has terminated at least once.
+func TerminationsOf(pod v1.Pod) []Termination {
+	terminations := []Termination{}
+	for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+		terminated := status.LastTerminationState.Terminated
+		if terminated == nil || terminated.FinishedAt.IsZero() {
+			continue
+		}
+		terminations = append(terminations, Termination{
+			Namespace:    pod.Namespace,
+			Pod:          pod.Name,
+			Container:    status.Name,
+			Reason:       terminated.Reason,
+			ExitCode:     terminated.ExitCode,
+			FinishedAt:   terminated.FinishedAt.Time,
+			RestartCount: status.RestartCount,
+		})
+	}
+	return terminations
+}
+
+// ClusterTerminations splits the terminations, sorted by FinishedAt, into
+// clusters in which each termination finished within the window of the
+// previous one.
+func ClusterTerminations(terminations []Termination, window time.Duration) [][]Termination {
+	clusters := [][]Termination{}
+	for i, termination := range terminations {
+		if i == 0 || termination.FinishedAt.Sub(terminations[i-1].FinishedAt) > window {
+			clusters = append(clusters, []Termination{})
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], termination)
+	}
+	return clusters
+}
@@ -0,0 +1,109 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestRestartsNearPod(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	restarted := func(namespace string, name string, nodeName string, finishedAt ...time.Time) *v1.Pod {
+		pod := nodePod(namespace, name, nodeName)
+		for i, finished := range finishedAt {
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+				Name:         []string{"main", "sidecar"}[i],
+				RestartCount: 1,
+				LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+					Reason:     "OOMKilled",
+					FinishedAt: metav1.NewTime(finished),
+				}},
+			})
+		}
+		return pod
+	}
+	client := testclient.NewSimpleClientset(
+		restarted("default", "nginx", "node-a-1", start.Add(30*time.Minute)),
+		restarted("default", "redis", "node-a-1", start, start.Add(45*time.Minute)),
+		restarted("kube-system", "fluentd", "node-a-1", start.Add(time.Minute)),
+		restarted("default", "web", "node-a-2", start),
+		nodePod("default", "pending", ""),
+	)
+
+	var testCases = []struct {
+		name          string
+		opts          nearby.PodOptions
+		terminations  []string
+		clusters      [][]string
+		clusterWindow time.Duration
+	}{
+		{
+			"defaults to the pod's namespace",
+			nearby.PodOptions{},
+			[]string{"redis/main", "nginx/main", "redis/sidecar"},
+			[][]string{{"redis/main"}, {"nginx/main", "redis/sidecar"}},
+			20 * time.Minute,
+		},
+		{
+			"with all namespaces",
+			nearby.PodOptions{AllNamespaces: true},
+			[]string{"redis/main", "fluentd/main", "nginx/main", "redis/sidecar"},
+			[][]string{{"redis/main", "fluentd/main"}, {"nginx/main"}, {"redis/sidecar"}},
+			5 * time.Minute,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := nearby.RestartsNearPod(context.Background(), client, "default", "nginx", testCase.opts)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.Node != "node-a-1" {
+				t.Errorf("Expected node: node-a-1, got: %v", result.Node)
+			}
+			if got := terminationNames(result.Terminations); !reflect.DeepEqual(testCase.terminations, got) {
+				t.Errorf("Expected terminations: %v, got: %v", testCase.terminations, got)
+			}
+			clusters := [][]string{}
+			for _, cluster := range nearby.ClusterTerminations(result.Terminations, testCase.clusterWindow) {
+				clusters = append(clusters, terminationNames(cluster))
+			}
+			if !reflect.DeepEqual(testCase.clusters, clusters) {
+				t.Errorf("Expected clusters: %v, got: %v", testCase.clusters, clusters)
+			}
+		})
+	}
+
+	t.Run("with an unscheduled pod, returns an error", func(t *testing.T) {
+		_, err := nearby.RestartsNearPod(context.Background(), client, "default", "pending", nearby.PodOptions{})
+		var unscheduled errs.ErrUnscheduled
+		if !errors.As(err, &unscheduled) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", unscheduled, err, err)
+		}
+	})
+
+	t.Run("with an unknown pod, returns a not found error", func(t *testing.T) {
+		_, err := nearby.RestartsNearPod(context.Background(), client, "default", "missing", nearby.PodOptions{})
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+}
+
+func terminationNames(terminations []nearby.Termination) []string {
+	names := []string{}
+	for _, termination := range terminations {
+		names = append(names, termination.Pod+"/"+termination.Container)
+	}
+	return names
+}
@@ -0,0 +1,133 @@
+package nearby
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// RecentRestartWindow is how long ago a container may have last terminated
+// for its restarts to count as recent.
+const RecentRestartWindow = time.Hour
+
+// The weights of each risk factor. A pod that has been OOMKilled, or that can
+// use memory without bound, is the most likely to put pressure on the node.
+const (
+	riskBestEffort       = 3
+	riskBurstable        = 1
+	riskNoMemoryLimit    = 2
+	riskNoCPULimit       = 1
+	riskOvercommit       = 1
+	riskRestarts         = 1
+	riskRecentRestarts   = 2
+	riskOOMKilled        = 3
+	overcommitRatioLimit = 2
+)
+
+// A Risk is a heuristic score of how likely a pod is to disturb the other
+// pods on its node, e.g. by using more memory or CPU than it requested.
+type Risk struct {
+	Score int
+	// Reasons describe the factors that added to the score, e.g. "OOMKilled".
+	Reasons []string
+}
+
+func (risk *Risk) add(score int, reason string) {
+	risk.Score += score
+	risk.Reasons = append(risk.Reasons, reason)
+}
+
+// RiskOf scores the pod by its QoS class, the ratio of its CPU and memory
+// limits to its requests, its restarts (recent if a container terminated
+// within RecentRestartWindow of now) and its OOMKilled containers.
+func RiskOf(pod *v1.Pod, now time.Time) Risk {
+	risk := Risk{}
+	switch QOSClassOf(pod) {
+	case v1.PodQOSBestEffort:
+		risk.add(riskBestEffort, "BestEffort")
+	case v1.PodQOSBurstable:
+		risk.add(riskBurstable, "Burstable")
+	}
+
+	resources := ResourcesOf(pod)
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		request := resources.Requests[name]
+		limit, hasLimit := resources.Limits[name]
+		switch {
+		case request.IsZero():
+			// Nothing is requested, which the QoS class already covers.
+		case !hasLimit && name == v1.ResourceMemory:
+			risk.add(riskNoMemoryLimit, "no memory limit")
+		case !hasLimit:
+			risk.add(riskNoCPULimit, "no cpu limit")
+		default:
+			ratio := float64(limit.MilliValue()) / float64(request.MilliValue())
+			if ratio >= overcommitRatioLimit {
+				risk.add(riskOvercommit, fmt.Sprintf("%v limit %.1fx request", name, ratio))
+			}
+		}
+	}
+
+	var restarts int32
+	var lastTerminated time.Time
+	oomKilled := []string{}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+		for _, terminated := range []*v1.ContainerStateTerminated{status.LastTerminationState.Terminated, status.State.Terminated} {
+			if terminated == nil {
+				continue
+			}
+			if terminated.FinishedAt.After(lastTerminated) {
+				lastTerminated = terminated.FinishedAt.Time
+			}
+			if terminated.Reason == "OOMKilled" && !contains(oomKilled, status.Name) {
+				oomKilled = append(oomKilled, status.Name)
+			}
+		}
+	}
+	switch {
+	case restarts > 0 && now.Sub(lastTerminated) <= RecentRestartWindow:
+		risk.add(riskRecentRestarts, fmt.Sprintf("restarts: %v (recent)", restarts))
+	case restarts > 0:
+		risk.add(riskRestarts, fmt.Sprintf("restarts: %v", restarts))
+	}
+	if len(oomKilled) > 0 {
+		sort.Strings(oomKilled)
+		risk.add(riskOOMKilled, fmt.Sprintf("OOMKilled (%v)", strings.Join(oomKilled, ",")))
+	}
+	return risk
+}
+
+// QOSClassOf returns the pod's QoS class from its status or, if it isn't
+// set yet, from the requests and limits of its containers.
+func QOSClassOf(pod *v1.Pod) v1.PodQOSClass {
+	if pod.Status.QOSClass != "" {
+		return pod.Status.QOSClass
+	}
+	bestEffort := true
+	guaranteed := true
+	for _, container := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			request, hasRequest := container.Resources.Requests[name]
+			limit, hasLimit := container.Resources.Limits[name]
+			if (hasRequest && !request.IsZero()) || (hasLimit && !limit.IsZero()) {
+				bestEffort = false
+			}
+			// Requests default to the limits.
+			if !hasLimit || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+	switch {
+	case bestEffort:
+		return v1.PodQOSBestEffort
+	case guaranteed:
+		return v1.PodQOSGuaranteed
+	}
+	return v1.PodQOSBurstable
+}
@@ -0,0 +1,107 @@
+package nearby_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestRiskOf(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	resources := func(requests v1.ResourceList, limits v1.ResourceList) *v1.Pod {
+		pod := nodePod("default", "app", "node-a-1")
+		pod.Spec.Containers = []v1.Container{{Name: "main", Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}}
+		return pod
+	}
+	cpuMemory := func(cpu string, memory string) v1.ResourceList {
+		return v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)}
+	}
+	terminated := func(pod *v1.Pod, restarts int32, reason string, finishedAt time.Time) *v1.Pod {
+		pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+			Name:         "main",
+			RestartCount: restarts,
+			LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+				Reason:     reason,
+				FinishedAt: metav1.NewTime(finishedAt),
+			}},
+		}}
+		return pod
+	}
+
+	var testCases = []struct {
+		name    string
+		pod     *v1.Pod
+		score   int
+		reasons []string
+	}{
+		{
+			"with equal requests and limits, has no risk",
+			resources(cpuMemory("500m", "1Gi"), cpuMemory("500m", "1Gi")),
+			0,
+			nil,
+		},
+		{
+			"without requests or limits, is BestEffort",
+			resources(nil, nil),
+			3,
+			[]string{"BestEffort"},
+		},
+		{
+			"with limits well over the requests, is overcommitted",
+			resources(cpuMemory("250m", "512Mi"), cpuMemory("1", "1Gi")),
+			3,
+			[]string{"Burstable", "cpu limit 4.0x request", "memory limit 2.0x request"},
+		},
+		{
+			"without limits, is unbounded",
+			resources(cpuMemory("250m", "512Mi"), nil),
+			4,
+			[]string{"Burstable", "no cpu limit", "no memory limit"},
+		},
+		{
+			"with a recent OOMKill",
+			terminated(resources(cpuMemory("500m", "1Gi"), cpuMemory("500m", "1Gi")), 3, "OOMKilled", now.Add(-5*time.Minute)),
+			5,
+			[]string{"restarts: 3 (recent)", "OOMKilled (main)"},
+		},
+		{
+			"with old restarts",
+			terminated(resources(cpuMemory("500m", "1Gi"), cpuMemory("500m", "1Gi")), 1, "Error", now.Add(-2*time.Hour)),
+			1,
+			[]string{"restarts: 1"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			risk := nearby.RiskOf(testCase.pod, now)
+			if risk.Score != testCase.score {
+				t.Errorf("Expected score: %v, got: %v (%v)", testCase.score, risk.Score, risk.Reasons)
+			}
+			if !reflect.DeepEqual(testCase.reasons, risk.Reasons) {
+				t.Errorf("Expected reasons: %v, got: %v", testCase.reasons, risk.Reasons)
+			}
+		})
+	}
+}
+
+func TestQOSClassOf(t *testing.T) {
+	pod := nodePod("default", "app", "node-a-1")
+	pod.Spec.Containers = []v1.Container{{
+		Name:      "main",
+		Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+	}}
+	if class := nearby.QOSClassOf(pod); class != v1.PodQOSGuaranteed {
+		t.Errorf("Expected QoS class: %v, got: %v", v1.PodQOSGuaranteed, class)
+	}
+
+	pod.Status.QOSClass = v1.PodQOSBurstable
+	if class := nearby.QOSClassOf(pod); class != v1.PodQOSBurstable {
+		t.Errorf("Expected the QoS class from the status, got: %v", class)
+	}
+}
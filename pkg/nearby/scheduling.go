@@ -0,0 +1,32 @@
+package nearby
+
+import (
+	v1 "k8s.io/api/core/v1"
+	corev1helpers "k8s.io/component-helpers/scheduling/corev1"
+	"k8s.io/component-helpers/scheduling/corev1/nodeaffinity"
+)
+
+// CandidateNodes returns the nodes an unscheduled pod could be scheduled on
+// based on its node selector, required node affinity and tolerations. Resource
+// requests and pod (anti-)affinity are not considered.
+func CandidateNodes(pod *v1.Pod, nodes []v1.Node) []v1.Node {
+	affinity := nodeaffinity.GetRequiredNodeAffinity(pod)
+	candidates := []v1.Node{}
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		matches, err := affinity.Match(&node)
+		if err != nil || !matches {
+			continue
+		}
+		_, untolerated := corev1helpers.FindMatchingUntoleratedTaint(node.Spec.Taints, pod.Spec.Tolerations, func(taint *v1.Taint) bool {
+			return taint.Effect == v1.TaintEffectNoSchedule || taint.Effect == v1.TaintEffectNoExecute
+		})
+		if untolerated {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	return candidates
+}
@@ -0,0 +1,93 @@
+package nearby_test
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestCandidateNodes(t *testing.T) {
+	nodes := []v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a-1", Labels: map[string]string{"disk": "ssd", "topology.kubernetes.io/zone": "us-east4-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a-2", Labels: map[string]string{"disk": "hdd", "topology.kubernetes.io/zone": "us-east4-a"}}},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b-1", Labels: map[string]string{"disk": "ssd", "topology.kubernetes.io/zone": "us-east4-b"}},
+			Spec: v1.NodeSpec{
+				Taints: []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-b-2", Labels: map[string]string{"disk": "ssd", "topology.kubernetes.io/zone": "us-east4-b"}},
+			Spec:       v1.NodeSpec{Unschedulable: true},
+		},
+	}
+
+	var testCases = []struct {
+		name  string
+		spec  v1.PodSpec
+		nodes []string
+	}{
+		{
+			"without constraints, skips tainted and cordoned nodes",
+			v1.PodSpec{},
+			[]string{"node-a-1", "node-a-2"},
+		},
+		{
+			"with a node selector",
+			v1.PodSpec{NodeSelector: map[string]string{"disk": "ssd"}},
+			[]string{"node-a-1"},
+		},
+		{
+			"with a toleration",
+			v1.PodSpec{
+				NodeSelector: map[string]string{"disk": "ssd"},
+				Tolerations:  []v1.Toleration{{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "gpu", Effect: v1.TaintEffectNoSchedule}},
+			},
+			[]string{"node-a-1", "node-b-1"},
+		},
+		{
+			"with required node affinity",
+			v1.PodSpec{
+				Affinity: &v1.Affinity{
+					NodeAffinity: &v1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+							NodeSelectorTerms: []v1.NodeSelectorTerm{{
+								MatchExpressions: []v1.NodeSelectorRequirement{{
+									Key:      "topology.kubernetes.io/zone",
+									Operator: v1.NodeSelectorOpIn,
+									Values:   []string{"us-east4-a"},
+								}},
+							}},
+						},
+					},
+				},
+			},
+			[]string{"node-a-1", "node-a-2"},
+		},
+		{
+			"with no matching nodes",
+			v1.PodSpec{NodeSelector: map[string]string{"disk": "nvme"}},
+			[]string{},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			pod := &v1.Pod{Spec: testCase.spec}
+			got := []string{}
+			for _, node := range nearby.CandidateNodes(pod, nodes) {
+				got = append(got, node.Name)
+			}
+			if len(got) != len(testCase.nodes) {
+				t.Fatalf("Expected candidate nodes: %v, got: %v", testCase.nodes, got)
+			}
+			for index := range got {
+				if got[index] != testCase.nodes[index] {
+					t.Errorf("Expected candidate nodes: %v, got: %v", testCase.nodes, got)
+				}
+			}
+		})
+	}
+}
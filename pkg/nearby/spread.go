@@ -0,0 +1,46 @@
+package nearby
+
+import (
+	"sort"
+
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// A Domain is a node, zone or region and the pods located in it.
+type Domain struct {
+	// Name is empty for pods whose domain is unknown (e.g. unscheduled pods).
+	Name string
+	Pods []string
+}
+
+// Spread groups the located pods by their domain at the given level
+// (topology.LevelNode, topology.LevelZone or topology.LevelRegion). Domains
+// are sorted by number of pods, most first, then by name.
+func Spread(locations []Location, level string) []Domain {
+	pods := map[string][]string{}
+	for _, location := range locations {
+		var name string
+		switch level {
+		case topology.LevelNode:
+			name = location.Node
+		case topology.LevelZone:
+			name = location.Zone
+		case topology.LevelRegion:
+			name = location.Region
+		}
+		pods[name] = append(pods[name], location.Pod.Name)
+	}
+
+	domains := []Domain{}
+	for name, podNames := range pods {
+		sort.Strings(podNames)
+		domains = append(domains, Domain{Name: name, Pods: podNames})
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if len(domains[i].Pods) != len(domains[j].Pods) {
+			return len(domains[i].Pods) > len(domains[j].Pods)
+		}
+		return domains[i].Name < domains[j].Name
+	})
+	return domains
+}
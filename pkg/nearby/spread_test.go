@@ -0,0 +1,54 @@
+package nearby_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+func TestSpread(t *testing.T) {
+	locations := []nearby.Location{
+		{Pod: *nodePod("default", "web-1", "node-a-1"), Node: "node-a-1", Zone: "us-east4-a", Region: "us-east4"},
+		{Pod: *nodePod("default", "web-2", "node-a-2"), Node: "node-a-2", Zone: "us-east4-a", Region: "us-east4"},
+		{Pod: *nodePod("default", "web-3", "node-b-1"), Node: "node-b-1", Zone: "us-east4-b", Region: "us-east4"},
+		{Pod: *nodePod("default", "web-4", "")},
+	}
+
+	var testCases = []struct {
+		level    string
+		expected []nearby.Domain
+	}{
+		{
+			topology.LevelNode,
+			[]nearby.Domain{
+				{Name: "", Pods: []string{"web-4"}},
+				{Name: "node-a-1", Pods: []string{"web-1"}},
+				{Name: "node-a-2", Pods: []string{"web-2"}},
+				{Name: "node-b-1", Pods: []string{"web-3"}},
+			},
+		},
+		{
+			topology.LevelZone,
+			[]nearby.Domain{
+				{Name: "us-east4-a", Pods: []string{"web-1", "web-2"}},
+				{Name: "", Pods: []string{"web-4"}},
+				{Name: "us-east4-b", Pods: []string{"web-3"}},
+			},
+		},
+		{
+			topology.LevelRegion,
+			[]nearby.Domain{
+				{Name: "us-east4", Pods: []string{"web-1", "web-2", "web-3"}},
+				{Name: "", Pods: []string{"web-4"}},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		got := nearby.Spread(locations, testCase.level)
+		if !reflect.DeepEqual(testCase.expected, got) {
+			t.Errorf("Expected %v spread: %+v, got: %+v", testCase.level, testCase.expected, got)
+		}
+	}
+}
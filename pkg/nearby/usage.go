@@ -0,0 +1,85 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// Usage is the CPU and memory used by a pod or node, as last reported by
+// metrics-server.
+type Usage struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// PodUsage returns the usage of the pods, summed over their containers, by
+// namespace and name. Pods without metrics yet (e.g. just started) are left
+// out. An errs.ErrMetricsUnavailable is returned if the metrics API isn't
+// served.
+func PodUsage(ctx context.Context, client metricsclientset.Interface, pods []v1.Pod) (map[types.NamespacedName]Usage, error) {
+	wanted := map[types.NamespacedName]bool{}
+	namespaces := []string{}
+	for _, pod := range pods {
+		wanted[types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name}] = true
+		if !contains(namespaces, pod.Namespace) {
+			namespaces = append(namespaces, pod.Namespace)
+		}
+	}
+
+	usage := map[types.NamespacedName]Usage{}
+	for _, namespace := range namespaces {
+		metrics, err := client.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, metricsError(err, "pod metrics", namespace)
+		}
+		for _, podMetrics := range metrics.Items {
+			key := types.NamespacedName{Namespace: podMetrics.Namespace, Name: podMetrics.Name}
+			if !wanted[key] {
+				continue
+			}
+			var podUsage Usage
+			for _, container := range podMetrics.Containers {
+				podUsage.CPU.Add(*container.Usage.Cpu())
+				podUsage.Memory.Add(*container.Usage.Memory())
+			}
+			usage[key] = podUsage
+		}
+	}
+	return usage, nil
+}
+
+// NodeUsage returns the usage of the named nodes. Nodes without metrics yet
+// are left out. An errs.ErrMetricsUnavailable is returned if the metrics API
+// isn't served.
+func NodeUsage(ctx context.Context, client metricsclientset.Interface, nodeNames []string) (map[string]Usage, error) {
+	metrics, err := client.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, metricsError(err, "node metrics", "")
+	}
+	usage := map[string]Usage{}
+	for _, nodeMetrics := range metrics.Items {
+		if contains(nodeNames, nodeMetrics.Name) {
+			usage[nodeMetrics.Name] = Usage{CPU: *nodeMetrics.Usage.Cpu(), Memory: *nodeMetrics.Usage.Memory()}
+		}
+	}
+	return usage, nil
+}
+
+// metricsError converts an error from the metrics API. Without
+// metrics-server, the API server reports the metrics.k8s.io resources as not
+// found, or as unavailable if the APIService exists but isn't served.
+func metricsError(err error, kind string, namespace string) error {
+	if apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err) {
+		return errs.ErrMetricsUnavailable{Err: err}
+	}
+	return fmt.Errorf("unable to fetch %v: %w", kind, errs.FromAPI(err, kind, namespace, ""))
+}
@@ -0,0 +1,106 @@
+package nearby_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestPodUsage(t *testing.T) {
+	client := metricsClient(t,
+		podMetrics("default", "nginx", "10m", "20Mi"),
+		podMetrics("kube-system", "fluentd", "5m", "50Mi"),
+		podMetrics("default", "other", "1", "1Gi"),
+	)
+	usage, err := nearby.PodUsage(context.Background(), client, []v1.Pod{
+		*nodePod("default", "nginx", "node-a-1"),
+		*nodePod("kube-system", "fluentd", "node-a-1"),
+		*nodePod("default", "new", "node-a-1"),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Errorf("Expected the usage of 2 pods, got: %v", usage)
+	}
+	nginx := usage[types.NamespacedName{Namespace: "default", Name: "nginx"}]
+	if nginx.CPU.String() != "20m" || nginx.Memory.String() != "40Mi" {
+		t.Errorf("Expected nginx to use 20m and 40Mi over its containers, got: %v and %v", nginx.CPU.String(), nginx.Memory.String())
+	}
+}
+
+func TestNodeUsage(t *testing.T) {
+	client := metricsClient(t,
+		&metricsv1beta1.NodeMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-a-1"},
+			Usage:      v1.ResourceList{v1.ResourceCPU: resource.MustParse("1500m"), v1.ResourceMemory: resource.MustParse("3Gi")},
+		},
+		&metricsv1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: "node-b-1"}},
+	)
+	usage, err := nearby.NodeUsage(context.Background(), client, []string{"node-a-1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cpu := usage["node-a-1"].CPU
+	if len(usage) != 1 || cpu.String() != "1500m" {
+		t.Errorf("Expected the usage of node-a-1, got: %v", usage)
+	}
+
+	t.Run("without metrics-server, returns a metrics unavailable error", func(t *testing.T) {
+		client := metricsfake.NewSimpleClientset()
+		client.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "nodes"}, "")
+		})
+		_, err := nearby.NodeUsage(context.Background(), client, []string{"node-a-1"})
+		var unavailable errs.ErrMetricsUnavailable
+		if !errors.As(err, &unavailable) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", unavailable, err, err)
+		}
+	})
+}
+
+// metricsClient returns a fake metrics client with the objects. They are
+// added to the tracker under the resources the client reads from (pods and
+// nodes), which differ from the ones NewSimpleClientset would guess from their
+// kinds.
+func metricsClient(t *testing.T, objects ...runtime.Object) *metricsfake.Clientset {
+	t.Helper()
+	client := metricsfake.NewSimpleClientset()
+	for _, object := range objects {
+		var err error
+		switch object := object.(type) {
+		case *metricsv1beta1.PodMetrics:
+			err = client.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("pods"), object, object.Namespace)
+		case *metricsv1beta1.NodeMetrics:
+			err = client.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("nodes"), object, "")
+		}
+		if err != nil {
+			t.Fatalf("adding metrics: %v", err)
+		}
+	}
+	return client
+}
+
+// podMetrics returns metrics for a pod with two containers, each using the
+// given CPU and memory.
+func podMetrics(namespace string, name string, cpu string, memory string) *metricsv1beta1.PodMetrics {
+	usage := v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)}
+	return &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Containers: []metricsv1beta1.ContainerMetrics{{Name: "main", Usage: usage}, {Name: "sidecar", Usage: usage}},
+	}
+}
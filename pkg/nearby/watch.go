@@ -0,0 +1,364 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// Watch event types.
+const (
+	EventAdded    = "ADDED"
+	EventModified = "MODIFIED"
+	EventDeleted  = "DELETED"
+)
+
+// A PodEvent is a change to a pod near the targets.
+type PodEvent struct {
+	Type     string
+	Neighbor Neighbor
+}
+
+// A NodeEvent is a node joining or leaving the topology, or changing its
+// Ready status.
+type NodeEvent struct {
+	Type string
+	Node v1.Node
+}
+
+// WatchPods calls handle with the pods on the nodes of the result's targets,
+// as EventAdded events sorted like PodsResult.Neighbors, and then with each
+// change until the context is done or handle returns an error. The nodes are
+// the ones in the result; they are not updated if the targets move.
+//
+// The pods are watched with informers, which list the pods again and resume
+// watching if the connection to the API server drops. Calls to handle are
+// never concurrent.
+func WatchPods(ctx context.Context, client kubernetes.Interface, result *PodsResult, opts PodOptions, handle func(events []PodEvent) error) error {
+	nodeNames := []string{}
+	nodeTargets := map[string][]string{}
+	namespaces := []string{}
+	for _, target := range result.Targets {
+		for _, nodeName := range target.Nodes {
+			if _, ok := nodeTargets[nodeName]; !ok {
+				nodeNames = append(nodeNames, nodeName)
+			}
+			nodeTargets[nodeName] = append(nodeTargets[nodeName], target.Pod.Name)
+		}
+		if !contains(namespaces, target.Pod.Namespace) {
+			namespaces = append(namespaces, target.Pod.Namespace)
+		}
+	}
+	if opts.AllNamespaces {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	// Results without targets (e.g. from PodsNearNode) list their nodes and
+	// namespaces directly.
+	if len(result.Targets) == 0 {
+		nodeNames = result.Nodes
+		namespaces = result.Namespaces
+	}
+
+	w := newWatcher(ctx)
+	defer w.stop()
+	send := func(eventType string, pod *v1.Pod) {
+		w.send(eventType, pod, func() error {
+			return handle([]PodEvent{{
+				Type:     eventType,
+				Neighbor: Neighbor{Pod: *pod, Near: nodeTargets[pod.Spec.NodeName]},
+			}})
+		})
+	}
+	// A field selector can only match a single node, so each node of each
+	// namespace is watched by its own informer.
+	stores := []cache.Store{}
+	onNodes := []func(obj interface{}) (*v1.Pod, bool){}
+	for _, namespace := range namespaces {
+		for _, nodeName := range nodeNames {
+			onNode := func(obj interface{}) (*v1.Pod, bool) {
+				pod, ok := obj.(*v1.Pod)
+				return pod, ok && pod.Spec.NodeName == nodeName
+			}
+			selector := fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
+			factory := informers.NewSharedInformerFactoryWithOptions(client, 0,
+				informers.WithNamespace(namespace),
+				informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+					options.FieldSelector = selector
+				}),
+			)
+			informer := factory.Core().V1().Pods().Informer()
+			_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+				AddFunc: func(obj interface{}, isInInitialList bool) {
+					// The initial list is sent sorted once every informer
+					// has synced.
+					if pod, ok := onNode(obj); ok && !isInInitialList {
+						send(EventAdded, pod)
+					}
+				},
+				UpdateFunc: func(oldObj, newObj interface{}) {
+					if unchanged(oldObj, newObj) {
+						return
+					}
+					// The node of a pod is only set once, but the filter is
+					// also applied here in case the selector is ignored.
+					_, wasOnNode := onNode(oldObj)
+					pod, isOnNode := onNode(newObj)
+					switch {
+					case wasOnNode && isOnNode:
+						send(EventModified, pod)
+					case isOnNode:
+						send(EventAdded, pod)
+					case wasOnNode:
+						send(EventDeleted, oldObj.(*v1.Pod))
+					}
+				},
+				DeleteFunc: func(obj interface{}) {
+					if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+						obj = tombstone.Obj
+					}
+					if pod, ok := onNode(obj); ok {
+						send(EventDeleted, pod)
+					}
+				},
+			})
+			if err != nil {
+				return fmt.Errorf("unable to watch pods: %v", err)
+			}
+			w.start(factory)
+			stores = append(stores, informer.GetStore())
+			onNodes = append(onNodes, onNode)
+			w.synced = append(w.synced, informer.HasSynced)
+		}
+	}
+
+	return w.run(func() error {
+		events := []PodEvent{}
+		for i, store := range stores {
+			for _, obj := range store.List() {
+				if pod, ok := onNodes[i](obj); ok {
+					w.listed(pod)
+					events = append(events, PodEvent{
+						Type:     EventAdded,
+						Neighbor: Neighbor{Pod: *pod, Near: nodeTargets[pod.Spec.NodeName]},
+					})
+				}
+			}
+		}
+		sort.SliceStable(events, func(i, j int) bool {
+			a, b := events[i].Neighbor.Pod, events[j].Neighbor.Pod
+			if a.Spec.NodeName != b.Spec.NodeName {
+				return a.Spec.NodeName < b.Spec.NodeName
+			}
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Name < b.Name
+		})
+		return handle(events)
+	})
+}
+
+// WatchNodes calls handle with the nodes in the result's topology, as
+// EventAdded events, and then each time a node joins or leaves the topology
+// (EventAdded or EventDeleted) or its Ready status changes (EventModified),
+// until the context is done or handle returns an error. Calls to handle are
+// never concurrent.
+func WatchNodes(ctx context.Context, client kubernetes.Interface, result *NodesResult, handle func(events []NodeEvent) error) error {
+	inTopology := func(obj interface{}) (*v1.Node, bool) {
+		node, ok := obj.(*v1.Node)
+		if !ok {
+			return nil, false
+		}
+		value, _, ok := topology.Value(node.Labels, result.TopologyKeys)
+		return node, ok && value == result.TopologyValue
+	}
+
+	w := newWatcher(ctx)
+	defer w.stop()
+	send := func(eventType string, node *v1.Node) {
+		w.send(eventType, node, func() error {
+			return handle([]NodeEvent{{Type: eventType, Node: *node}})
+		})
+	}
+	factory := informers.NewSharedInformerFactory(client, 0)
+	informer := factory.Core().V1().Nodes().Informer()
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if node, ok := inTopology(obj); ok && !isInInitialList {
+				send(EventAdded, node)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if unchanged(oldObj, newObj) {
+				return
+			}
+			oldNode, wasIn := inTopology(oldObj)
+			node, isIn := inTopology(newObj)
+			switch {
+			case wasIn && isIn:
+				// Nodes update their status often (e.g. heartbeats), so only
+				// report changes to Ready.
+				if readyStatus(*oldNode) != readyStatus(*node) {
+					send(EventModified, node)
+				}
+			case isIn:
+				send(EventAdded, node)
+			case wasIn:
+				send(EventDeleted, oldNode)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if node, ok := inTopology(obj); ok {
+				send(EventDeleted, node)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to watch nodes: %v", err)
+	}
+	w.start(factory)
+	w.synced = append(w.synced, informer.HasSynced)
+
+	return w.run(func() error {
+		nodes := []v1.Node{}
+		for _, obj := range informer.GetStore().List() {
+			if node, ok := inTopology(obj); ok {
+				w.listed(node)
+				nodes = append(nodes, *node)
+			}
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Name < nodes[j].Name
+		})
+		events := []NodeEvent{}
+		for _, node := range nodes {
+			events = append(events, NodeEvent{Type: EventAdded, Node: node})
+		}
+		return handle(events)
+	})
+}
+
+// unchanged reports whether an update is only the informer listing the
+// object again (e.g. on a resync or after a dropped connection), with the same
+// resource version. Objects without a resource version are always changed.
+func unchanged(oldObj, newObj interface{}) bool {
+	oldObject, oldOK := oldObj.(metav1.Object)
+	newObject, newOK := newObj.(metav1.Object)
+	return oldOK && newOK && newObject.GetResourceVersion() != "" &&
+		oldObject.GetResourceVersion() == newObject.GetResourceVersion()
+}
+
+// readyStatus returns the status of the node's Ready condition.
+func readyStatus(node v1.Node) v1.ConditionStatus {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status
+		}
+	}
+	return v1.ConditionUnknown
+}
+
+// A watcher serializes the events of several informers. Events are held back
+// until the initial list has been handled.
+type watcher struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	factories []informers.SharedInformerFactory
+	synced    []cache.InformerSynced
+	// ready is closed once the initial list has been handled.
+	ready chan struct{}
+	// initial holds the resource version of each object in the initial
+	// list, by namespace/name.
+	initial map[string]string
+	mutex   sync.Mutex
+	err     error
+}
+
+func newWatcher(ctx context.Context) *watcher {
+	ctx, cancel := context.WithCancel(ctx)
+	return &watcher{ctx: ctx, cancel: cancel, ready: make(chan struct{}), initial: map[string]string{}}
+}
+
+// start starts the factory's informers until the watch stops.
+func (w *watcher) start(factory informers.SharedInformerFactory) {
+	factory.Start(w.ctx.Done())
+	w.factories = append(w.factories, factory)
+}
+
+// stop stops the informers and waits for them to finish.
+func (w *watcher) stop() {
+	w.cancel()
+	for _, factory := range w.factories {
+		factory.Shutdown()
+	}
+}
+
+// listed records that the object is part of the initial list. It must be
+// called by the handleInitial function given to run.
+func (w *watcher) listed(object metav1.Object) {
+	w.initial[cache.MetaObjectToName(object).String()] = object.GetResourceVersion()
+}
+
+// send calls handle for an event of the object once the initial list has
+// been handled, unless the watch has stopped. Objects added or modified
+// after the informers synced may already be in the initial list: their
+// event is dropped if the initial list has the same version.
+func (w *watcher) send(eventType string, object metav1.Object, handle func() error) {
+	select {
+	case <-w.ready:
+	case <-w.ctx.Done():
+		return
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.ctx.Err() != nil {
+		return
+	}
+	key := cache.MetaObjectToName(object).String()
+	version, ok := w.initial[key]
+	delete(w.initial, key)
+	if ok && eventType != EventDeleted && version != "" && version == object.GetResourceVersion() {
+		return
+	}
+	if err := handle(); err != nil {
+		w.err = err
+		w.cancel()
+	}
+}
+
+// run waits for the informers to sync, calls handleInitial and then waits
+// until the watch stops. It returns the first error returned by a handler,
+// or nil if the context is done (e.g. on Ctrl-C).
+func (w *watcher) run(handleInitial func() error) error {
+	if !cache.WaitForCacheSync(w.ctx.Done(), w.synced...) {
+		return w.result()
+	}
+	w.mutex.Lock()
+	err := handleInitial()
+	w.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	close(w.ready)
+	<-w.ctx.Done()
+	return w.result()
+}
+
+func (w *watcher) result() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.err
+}
@@ -0,0 +1,321 @@
+package nearby_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestWatchPods(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		nodePod("default", "nginx", "node-a-1"),
+		nodePod("default", "redis", "node-a-1"),
+		nodePod("default", "web", "node-a-2"),
+	)
+	watching := watchStarted(client)
+	result := &nearby.PodsResult{
+		Targets: []nearby.Target{{Pod: *nodePod("default", "nginx", "node-a-1"), Nodes: []string{"node-a-1"}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := make(chan []string, 10)
+	done := make(chan error)
+	go func() {
+		done <- nearby.WatchPods(ctx, client, result, nearby.PodOptions{}, func(events []nearby.PodEvent) error {
+			batch := []string{}
+			for _, event := range events {
+				batch = append(batch, event.Type+" "+event.Neighbor.Pod.Name+" near "+event.Neighbor.Near[0])
+			}
+			batches <- batch
+			return nil
+		})
+	}()
+
+	expectBatch(t, batches, "ADDED nginx near nginx", "ADDED redis near nginx")
+	waitFor(t, watching)
+
+	pods := client.CoreV1().Pods("default")
+	_, err := pods.Create(ctx, nodePod("default", "cache", "node-a-1"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "ADDED cache near nginx")
+
+	// Pods on other nodes are ignored.
+	_, err = pods.Create(ctx, nodePod("default", "api", "node-a-2"), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	redis := nodePod("default", "redis", "node-a-1")
+	redis.Status.Phase = v1.PodFailed
+	_, err = pods.UpdateStatus(ctx, redis, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "MODIFIED redis near nginx")
+
+	err = pods.Delete(ctx, "cache", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "DELETED cache near nginx")
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error after cancel, got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the watch to stop")
+	}
+}
+
+func TestWatchPodsOnSeveralNodes(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		nodePod("default", "nginx", "node-a-1"),
+		nodePod("default", "redis", "node-a-1"),
+		nodePod("default", "web", "node-a-2"),
+		nodePod("default", "api", "node-b-1"),
+	)
+	result := &nearby.PodsResult{
+		Targets: []nearby.Target{{Pod: *nodePod("default", "nginx", "node-a-1"), Nodes: []string{"node-a-1", "node-a-2"}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batches := make(chan []string, 10)
+	done := make(chan error)
+	go func() {
+		done <- nearby.WatchPods(ctx, client, result, nearby.PodOptions{}, func(events []nearby.PodEvent) error {
+			batch := []string{}
+			for _, event := range events {
+				batch = append(batch, event.Type+" "+event.Neighbor.Pod.Name)
+			}
+			batches <- batch
+			return nil
+		})
+	}()
+
+	expectBatch(t, batches, "ADDED nginx", "ADDED redis", "ADDED web")
+	cancel()
+	<-done
+
+	// Each node is listed with its own field selector, so the API server
+	// only sends the pods on the watched nodes.
+	selectors := []string{}
+	for _, action := range client.Actions() {
+		if list, ok := action.(clienttesting.ListAction); ok && action.GetResource().Resource == "pods" {
+			selectors = append(selectors, list.GetListRestrictions().Fields.String())
+		}
+	}
+	sort.Strings(selectors)
+	expected := []string{"spec.nodeName=node-a-1", "spec.nodeName=node-a-2"}
+	if !reflect.DeepEqual(expected, selectors) {
+		t.Errorf("Expected pod lists with field selectors: %v, got: %v", expected, selectors)
+	}
+}
+
+func TestWatchPodsAddedWhileSyncing(t *testing.T) {
+	client := testclient.NewSimpleClientset(
+		nodePod("default", "nginx", "node-a-1"),
+	)
+	// The pod is added as soon as the informer watches, usually before the
+	// initial list is taken from its cache.
+	cachePod := nodePod("default", "cache", "node-a-1")
+	cachePod.ResourceVersion = "2"
+	client.PrependWatchReactor("pods", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		watcher, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, watcher, client.Tracker().Add(cachePod)
+	})
+	result := &nearby.PodsResult{
+		Targets: []nearby.Target{{Pod: *nodePod("default", "nginx", "node-a-1"), Nodes: []string{"node-a-1"}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan string, 10)
+	go nearby.WatchPods(ctx, client, result, nearby.PodOptions{}, func(batch []nearby.PodEvent) error {
+		for _, event := range batch {
+			events <- event.Type + " " + event.Neighbor.Pod.Name
+		}
+		return nil
+	})
+
+	got := []string{}
+	timeout := time.After(time.Second)
+collect:
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		case <-timeout:
+			break collect
+		}
+	}
+	// The pod is in the initial list or added after it, but not both.
+	sort.Strings(got)
+	expected := []string{"ADDED cache", "ADDED nginx"}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected events: %v, got: %v", expected, got)
+	}
+}
+
+func TestWatchPodsUnchangedUpdate(t *testing.T) {
+	redis := nodePod("default", "redis", "node-a-1")
+	redis.ResourceVersion = "1"
+	client := testclient.NewSimpleClientset(redis)
+	watching := watchStarted(client)
+	result := &nearby.PodsResult{
+		Targets: []nearby.Target{{Pod: *nodePod("default", "nginx", "node-a-1"), Nodes: []string{"node-a-1"}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []string, 10)
+	go nearby.WatchPods(ctx, client, result, nearby.PodOptions{}, func(events []nearby.PodEvent) error {
+		batch := []string{}
+		for _, event := range events {
+			batch = append(batch, event.Type+" "+event.Neighbor.Pod.Name+" "+string(event.Neighbor.Pod.Status.Phase))
+		}
+		batches <- batch
+		return nil
+	})
+
+	expectBatch(t, batches, "ADDED redis ")
+	waitFor(t, watching)
+
+	pods := client.CoreV1().Pods("default")
+	failed := redis.DeepCopy()
+	failed.ResourceVersion = "2"
+	failed.Status.Phase = v1.PodFailed
+	_, err := pods.UpdateStatus(ctx, failed, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "MODIFIED redis Failed")
+
+	// An update with the same resource version, as sent when the informer
+	// lists the pods again, is not a change.
+	_, err = pods.UpdateStatus(ctx, failed, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	succeeded := failed.DeepCopy()
+	succeeded.ResourceVersion = "3"
+	succeeded.Status.Phase = v1.PodSucceeded
+	_, err = pods.UpdateStatus(ctx, succeeded, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "MODIFIED redis Succeeded")
+}
+
+func TestWatchNodes(t *testing.T) {
+	zone := map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}
+	client := testclient.NewSimpleClientset(
+		testNode("node-a-1", zone),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+	)
+	watching := watchStarted(client)
+	result := &nearby.NodesResult{
+		TopologyKeys:  []string{"topology.kubernetes.io/zone"},
+		TopologyValue: "us-east4-a",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []string, 10)
+	go func() {
+		_ = nearby.WatchNodes(ctx, client, result, func(events []nearby.NodeEvent) error {
+			batch := []string{}
+			for _, event := range events {
+				batch = append(batch, event.Type+" "+event.Node.Name)
+			}
+			batches <- batch
+			return nil
+		})
+	}()
+
+	expectBatch(t, batches, "ADDED node-a-1")
+	waitFor(t, watching)
+
+	nodes := client.CoreV1().Nodes()
+	_, err := nodes.Create(ctx, testNode("node-a-2", zone), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "ADDED node-a-2")
+
+	// Status changes other than Ready are ignored.
+	node := testNode("node-a-2", zone)
+	node.Status.NodeInfo.KubeletVersion = "v1.32.0"
+	_, err = nodes.UpdateStatus(ctx, node, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}
+	_, err = nodes.UpdateStatus(ctx, node, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "MODIFIED node-a-2")
+
+	// Moving to another zone leaves the topology.
+	_, err = nodes.Update(ctx, testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expectBatch(t, batches, "DELETED node-a-1")
+}
+
+// watchStarted returns a channel that receives each time a watch is started.
+// The fake clientset drops events sent before the informer is watching.
+func watchStarted(client *testclient.Clientset) chan struct{} {
+	started := make(chan struct{}, 10)
+	client.PrependWatchReactor("*", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		watcher, err := client.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		started <- struct{}{}
+		return true, watcher, err
+	})
+	return started
+}
+
+func waitFor(t *testing.T, started chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the watch to start")
+	}
+}
+
+func expectBatch(t *testing.T, batches chan []string, expected ...string) {
+	t.Helper()
+	select {
+	case batch := <-batches:
+		if len(batch) != len(expected) {
+			t.Fatalf("Expected events: %v, got: %v", expected, batch)
+		}
+		for i := range batch {
+			if batch[i] != expected[i] {
+				t.Errorf("Expected events: %v, got: %v", expected, batch)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for events: %v", expected)
+	}
+}
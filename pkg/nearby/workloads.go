@@ -0,0 +1,154 @@
+package nearby
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+)
+
+// Supported workload kinds for TYPE/NAME targets.
+const (
+	KindDaemonSet   = "DaemonSet"
+	KindDeployment  = "Deployment"
+	KindJob         = "Job"
+	KindPod         = "Pod"
+	KindReplicaSet  = "ReplicaSet"
+	KindStatefulSet = "StatefulSet"
+)
+
+// workloadKinds maps kubectl resource names and short names to kinds.
+var workloadKinds = map[string]string{
+	"daemonset":    KindDaemonSet,
+	"daemonsets":   KindDaemonSet,
+	"ds":           KindDaemonSet,
+	"deploy":       KindDeployment,
+	"deployment":   KindDeployment,
+	"deployments":  KindDeployment,
+	"job":          KindJob,
+	"jobs":         KindJob,
+	"po":           KindPod,
+	"pod":          KindPod,
+	"pods":         KindPod,
+	"replicaset":   KindReplicaSet,
+	"replicasets":  KindReplicaSet,
+	"rs":           KindReplicaSet,
+	"statefulset":  KindStatefulSet,
+	"statefulsets": KindStatefulSet,
+	"sts":          KindStatefulSet,
+}
+
+// ParseTarget splits a TYPE/NAME target (e.g. deploy/checkout) into a kind and
+// name. A target without a type is a pod name.
+func ParseTarget(target string) (kind string, name string, err error) {
+	resource, name, found := strings.Cut(target, "/")
+	if !found {
+		return KindPod, target, nil
+	}
+	kind, ok := workloadKinds[strings.ToLower(resource)]
+	if !ok {
+		return "", "", errs.ErrUsage{Err: fmt.Errorf("unsupported resource type: %v (expected one of: pod, deploy, sts, ds, job, rs)", resource)}
+	}
+	if name == "" {
+		return "", "", errs.ErrUsage{Err: fmt.Errorf("a name is required for resource type: %v", resource)}
+	}
+	return kind, name, nil
+}
+
+// WorkloadPods returns the pods controlled by the given workload. Pods are
+// matched by the workload's selector and then filtered by owner so that
+// overlapping selectors don't pull in pods of other workloads.
+func WorkloadPods(ctx context.Context, clientset kubernetes.Interface, namespace string, kind string, name string) ([]v1.Pod, error) {
+	var selector *metav1.LabelSelector
+	owners := map[types.UID]bool{}
+	switch kind {
+	case KindDeployment:
+		deployment, err := clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch deployment: %w", errs.FromAPI(err, "deployment", namespace, name))
+		}
+		selector = deployment.Spec.Selector
+		// Deployments own ReplicaSets, which in turn own the pods.
+		replicaSetSelector, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for deployment %v: %v", name, err)
+		}
+		replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: replicaSetSelector.String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch replica sets for deployment %v: %w", name, errs.FromAPI(err, "replicasets", namespace, ""))
+		}
+		for _, replicaSet := range replicaSets.Items {
+			if isControlledBy(replicaSet.ObjectMeta, deployment.UID) {
+				owners[replicaSet.UID] = true
+			}
+		}
+	case KindStatefulSet:
+		statefulSet, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch stateful set: %w", errs.FromAPI(err, "statefulset", namespace, name))
+		}
+		selector = statefulSet.Spec.Selector
+		owners[statefulSet.UID] = true
+	case KindDaemonSet:
+		daemonSet, err := clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch daemon set: %w", errs.FromAPI(err, "daemonset", namespace, name))
+		}
+		selector = daemonSet.Spec.Selector
+		owners[daemonSet.UID] = true
+	case KindReplicaSet:
+		replicaSet, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch replica set: %w", errs.FromAPI(err, "replicaset", namespace, name))
+		}
+		selector = replicaSet.Spec.Selector
+		owners[replicaSet.UID] = true
+	case KindJob:
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch job: %w", errs.FromAPI(err, "job", namespace, name))
+		}
+		selector = job.Spec.Selector
+		owners[job.UID] = true
+	default:
+		return nil, fmt.Errorf("unsupported workload kind: %v", kind)
+	}
+
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector for %v %v: %v", strings.ToLower(kind), name, err)
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: podSelector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch pods for %v %v: %w", strings.ToLower(kind), name, errs.FromAPI(err, "pods", namespace, ""))
+	}
+
+	workloadPods := []v1.Pod{}
+	for _, pod := range pods.Items {
+		for owner := range owners {
+			if isControlledBy(pod.ObjectMeta, owner) {
+				workloadPods = append(workloadPods, pod)
+				break
+			}
+		}
+	}
+	if len(workloadPods) == 0 {
+		return nil, errs.ErrNotFound{Kind: "pods for " + strings.ToLower(kind), Namespace: namespace, Name: name}
+	}
+	return workloadPods, nil
+}
+
+func isControlledBy(object metav1.ObjectMeta, owner types.UID) bool {
+	controller := metav1.GetControllerOfNoCopy(&object)
+	return controller != nil && controller.UID == owner
+}
@@ -0,0 +1,125 @@
+package nearby_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+)
+
+func TestParseTarget(t *testing.T) {
+	var testCases = []struct {
+		target string
+		kind   string
+		name   string
+	}{
+		{"nginx-abc123", "Pod", "nginx-abc123"},
+		{"pod/nginx-abc123", "Pod", "nginx-abc123"},
+		{"deploy/checkout", "Deployment", "checkout"},
+		{"deployments/checkout", "Deployment", "checkout"},
+		{"sts/redis", "StatefulSet", "redis"},
+		{"ds/fluentd", "DaemonSet", "fluentd"},
+		{"job/migrate", "Job", "migrate"},
+		{"rs/checkout-abc123", "ReplicaSet", "checkout-abc123"},
+	}
+	for _, testCase := range testCases {
+		kind, name, err := nearby.ParseTarget(testCase.target)
+		if err != nil {
+			t.Errorf("Unexpected error parsing %v: %v", testCase.target, err)
+		}
+		if kind != testCase.kind || name != testCase.name {
+			t.Errorf("Expected ParseTarget(%v) to return: %v %v, got: %v %v", testCase.target, testCase.kind, testCase.name, kind, name)
+		}
+	}
+
+	for _, target := range []string{"svc/checkout", "deploy/"} {
+		_, _, err := nearby.ParseTarget(target)
+		if err == nil {
+			t.Errorf("Expected an error parsing %v", target)
+		}
+	}
+}
+
+func TestWorkloadPods(t *testing.T) {
+	labels := map[string]string{"app": "checkout"}
+	selector := &metav1.LabelSelector{MatchLabels: labels}
+	clientset := testclient.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "default", UID: "deploy-uid"},
+			Spec:       appsv1.DeploymentSpec{Selector: selector},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "checkout-abc", Namespace: "default", UID: "rs-uid", Labels: labels,
+				OwnerReferences: []metav1.OwnerReference{controllerReference("Deployment", "checkout", "deploy-uid")},
+			},
+			Spec: appsv1.ReplicaSetSpec{Selector: selector},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-db", Namespace: "default", UID: "sts-uid"},
+			Spec:       appsv1.StatefulSetSpec{Selector: selector},
+		},
+		ownedPod("checkout-abc-1", labels, "ReplicaSet", "checkout-abc", "rs-uid"),
+		ownedPod("checkout-abc-2", labels, "ReplicaSet", "checkout-abc", "rs-uid"),
+		// Shares the deployment's labels, but belongs to the stateful set.
+		ownedPod("checkout-db-0", labels, "StatefulSet", "checkout-db", "sts-uid"),
+	)
+
+	var testCases = []struct {
+		kind string
+		name string
+		pods []string
+	}{
+		{nearby.KindDeployment, "checkout", []string{"checkout-abc-1", "checkout-abc-2"}},
+		{nearby.KindReplicaSet, "checkout-abc", []string{"checkout-abc-1", "checkout-abc-2"}},
+		{nearby.KindStatefulSet, "checkout-db", []string{"checkout-db-0"}},
+	}
+	for _, testCase := range testCases {
+		pods, err := nearby.WorkloadPods(context.Background(), clientset, "default", testCase.kind, testCase.name)
+		if err != nil {
+			t.Errorf("Unexpected error for %v %v: %v", testCase.kind, testCase.name, err)
+		}
+		names := []string{}
+		for _, pod := range pods {
+			names = append(names, pod.Name)
+		}
+		sort.Strings(names)
+		if len(names) != len(testCase.pods) {
+			t.Errorf("Expected pods for %v %v: %v, got: %v", testCase.kind, testCase.name, testCase.pods, names)
+			continue
+		}
+		for index := range names {
+			if names[index] != testCase.pods[index] {
+				t.Errorf("Expected pods for %v %v: %v, got: %v", testCase.kind, testCase.name, testCase.pods, names)
+			}
+		}
+	}
+
+	_, err := nearby.WorkloadPods(context.Background(), clientset, "default", nearby.KindDaemonSet, "missing")
+	if err == nil {
+		t.Errorf("Expected an error for a missing daemon set")
+	}
+}
+
+func controllerReference(kind string, name string, uid types.UID) metav1.OwnerReference {
+	controller := true
+	return metav1.OwnerReference{Kind: kind, Name: name, UID: uid, Controller: &controller}
+}
+
+func ownedPod(name string, labels map[string]string, ownerKind string, ownerName string, ownerUID types.UID) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{controllerReference(ownerKind, ownerName, ownerUID)},
+		},
+	}
+}
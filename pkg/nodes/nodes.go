@@ -3,38 +3,61 @@ package nodes
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
 	"github.com/leejones/kubectl-nearby/pkg/output"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
 )
 
 // A NodesCLI is used to create a command line interface for listing nearby
 // nodes.
 type NodesCLI struct {
-	Client kubernetes.Interface
+	Client  kubernetes.Interface
+	Metrics metricsclientset.Interface
+	// ErrOut receives warnings, such as the metrics API being unavailable.
+	// It defaults to os.Stderr.
+	ErrOut io.Writer
 }
 
+// ErrNodeNameRequired is returned when no node name, pod, IP or provider ID
+// is given.
 type ErrNodeNameRequired struct{}
 
 func (err ErrNodeNameRequired) Error() string {
-	return "a node name is required"
+	return "a node name, pod, IP or provider ID is required"
+}
+
+func (err ErrNodeNameRequired) ExitCode() int {
+	return errs.ExitUsage
 }
 
 // Execute writes a list of nearby nodes to the given io.Writer and returns an
 // error.
 func (n *NodesCLI) Execute(args []string, writer io.Writer) error {
+	return n.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (n *NodesCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
 	var nodeName string
 	var remainingArgs []string
 
@@ -56,133 +79,310 @@ func (n *NodesCLI) Execute(args []string, writer io.Writer) error {
 
 	f := flag.NewFlagSet("kubectl nearby nodes", flag.ContinueOnError)
 	f.Usage = func() {
-		fmt.Fprintf(f.Output(), "List nodes in the same node.\n\nUSAGE\n\n  %s node NODE [OPTIONS]\n\nOPTIONS\n\n", os.Args[0])
+		fmt.Fprintf(f.Output(), "List nodes in the same zone.\n\nUSAGE\n\n  %s nodes NODE [OPTIONS]\n  %s nodes --pod POD [OPTIONS]\n  %s nodes --ip IP [OPTIONS]\n  %s nodes --provider-id ID [OPTIONS]\n\nOPTIONS\n\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 		f.PrintDefaults()
 	}
 	f.SetOutput(ioutil.Discard)
 
-	kubeconfig := f.String("kubeconfig", "", fmt.Sprintf("(optional) An absolute path to the kubeconfig file (defaults to the value of KUBECONFIG from the ENV if set or the file %s if present)", clientcmd.RecommendedHomeFile))
+	var connection client.Flags
+	connection.AddFlags(f)
+	var outputFormat string
+	f.StringVar(&outputFormat, "output", "", fmt.Sprintf("Output format. One of: %s", strings.Join(output.Formats, ", ")))
+	f.StringVar(&outputFormat, "o", "", "Shorthand for --output")
+	podName := f.String("pod", "", "List nodes near the pod's node, used instead of a node name")
+	ip := f.String("ip", "", "List nodes near the node with the address (e.g. its internal IP), used instead of a node name")
+	providerID := f.String("provider-id", "", "List nodes near the node with the provider ID (e.g. aws:///us-east-1a/i-0123), used instead of a node name")
+	level := f.String("topology", topology.LevelZone, "List nodes sharing the node's topology. One of: zone, region, or a node label key (e.g. example.com/rack)")
+	var watch bool
+	f.BoolVar(&watch, "watch", false, "After listing the nodes, watch for nodes joining or leaving the topology or changing their Ready status (stop with Ctrl-C)")
+	f.BoolVar(&watch, "w", false, "Shorthand for --watch")
+	showUsage := f.Bool("usage", false, "Show the CPU and memory used by each node (and the share of its allocatable), from metrics-server, sorted by CPU")
+	sortBy := f.String("sort-by", "", "With --usage, sort the nodes by: cpu (the default) or memory")
+	var topologyKeys cli.StringsFlag
+	f.Var(&topologyKeys, "topology-key", "A node label key used to find nearby nodes (can be repeated, keys are tried in order and override --topology). The zone and region labels fall back to their deprecated failure-domain labels")
 
 	err := f.Parse(remainingArgs)
 	if err == flag.ErrHelp {
-		usage(f, writer)
+		cli.Usage(f, writer)
 		return nil
 	} else if err != nil {
-		return fmt.Errorf("error parsing CLI arguments: %v", err)
+		return errs.ErrUsage{Err: fmt.Errorf("error parsing CLI arguments: %v", err)}
 	}
 
-	if nodeName == "" {
+	targets := 0
+	for _, target := range []string{nodeName, *podName, *ip, *providerID} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets == 0 {
 		return ErrNodeNameRequired{}
+	} else if targets > 1 {
+		return errs.ErrUsage{Err: fmt.Errorf("only one of a node name, a pod, an IP and a provider ID can be given")}
+	}
+	if *showUsage && watch {
+		return errs.ErrUsage{Err: fmt.Errorf("--usage cannot be used with --watch")}
+	}
+	if *sortBy != "" && !*showUsage {
+		return errs.ErrUsage{Err: fmt.Errorf("--sort-by requires --usage")}
+	}
+	if *sortBy != "" && *sortBy != "cpu" && *sortBy != "memory" {
+		return errs.ErrUsage{Err: fmt.Errorf("invalid --sort-by: %q (must be cpu or memory)", *sortBy)}
+	}
+
+	var printer output.Printer
+	if watch {
+		printer, err = output.NewStreamPrinter(outputFormat)
+	} else {
+		printer, err = output.NewPrinter(outputFormat)
+	}
+	if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	keys := topology.WithFallbacks(topologyKeys)
+	if len(keys) == 0 {
+		keys, err = topology.Keys(*level)
+		if err != nil {
+			return errs.ErrUsage{Err: err}
+		}
 	}
 
 	if n.Client == nil {
-		n.Client, err = DefaultClient(*kubeconfig)
+		n.Client, err = connection.NewClient()
 		if err != nil {
 			return fmt.Errorf("error setting up default client: %v", err)
 		}
 	}
 
-	node, err := n.Client.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("unable fetch node: %v", err)
+	if *ip != "" || *providerID != "" {
+		var node *v1.Node
+		if *ip != "" {
+			node, err = nearby.NodeByIP(ctx, n.Client, *ip)
+		} else {
+			node, err = nearby.NodeByProviderID(ctx, n.Client, *providerID)
+		}
+		if err != nil {
+			return err
+		}
+		nodeName = node.Name
 	}
 
-	zone, ok := node.Labels["topology.kubernetes.io/zone"]
-	if !ok {
-		return fmt.Errorf("unable to find label 'topology.kubernetes.io/zone' on node: %v", node.Name)
+	var result *nearby.NodesResult
+	if *podName != "" {
+		namespace, err := connection.CurrentNamespace()
+		if err != nil {
+			return err
+		}
+		result, err = nearby.NodesNearPod(ctx, n.Client, namespace, *podName, nearby.NodeOptions{TopologyKeys: keys})
+		if err != nil {
+			return err
+		}
+	} else {
+		result, err = nearby.NodesNearNode(ctx, n.Client, nodeName, nearby.NodeOptions{TopologyKeys: keys})
+		if err != nil {
+			return err
+		}
 	}
 
-	nearbyNodes, err := n.Client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
-		LabelSelector: fmt.Sprintf("topology.kubernetes.io/zone=%v", zone),
-	})
-	if err != nil {
-		return fmt.Errorf("unable to fetch nearby nodes: %v", err)
-	}
-	nodesOutput := [][]string{
-		{"NAME", "STATUS", "ROLES", "AGE", "VERSION", "ZONE"},
+	if watch {
+		err = nearby.WatchNodes(ctx, n.Client, result, func(events []nearby.NodeEvent) error {
+			table := output.Table{Columns: nodeColumns(keys, false, "EVENT")}
+			for _, event := range events {
+				table.Rows = append(table.Rows, nodeRow(event.Node, keys, nil, event.Type))
+			}
+			err := printer.Print(table, writer)
+			if err != nil {
+				return fmt.Errorf("printing output: %v", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("could not watch nodes: %w", err)
+		}
+		return nil
 	}
 
-	for _, node := range nearbyNodes.Items {
-		roles := []string{}
-		for key := range node.Labels {
-			if strings.HasPrefix(key, "node-role.kubernetes.io/") {
-				roleParts := strings.Split(key, "/")
-				if len(roleParts) == 2 {
-					roles = append(roles, roleParts[1])
-				}
+	var usage map[string]nearby.Usage
+	if *showUsage {
+		if n.Metrics == nil {
+			n.Metrics, err = connection.NewMetricsClient()
+			if err != nil {
+				return err
 			}
 		}
-		var rolesOutput string
-		if len(roles) > 0 {
-			rolesOutput = strings.Join(roles, ",")
-		} else {
-			rolesOutput = "<none>"
-		}
-		zone, ok := node.Labels["topology.kubernetes.io/zone"]
-		if !ok {
-			zone = "<unknown>"
-		}
-		age := output.Age(time.Since(node.CreationTimestamp.Time))
-		status := "<unknown>"
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == "Ready" {
-				switch condition.Status {
-				case v1.ConditionTrue:
-					status = "Ready"
-				case v1.ConditionFalse:
-					status = "NotReady"
-				case v1.ConditionUnknown:
-					status = "Unknown"
-				}
-			}
+		usage, err = nodeUsage(ctx, n.Metrics, result.Nodes, *sortBy, n.errOut())
+		if err != nil {
+			return err
 		}
-		nodesOutput = append(nodesOutput, []string{
-			node.Name, status, rolesOutput, age, node.Status.NodeInfo.KubeletVersion, zone,
-		})
 	}
-	output, err := output.Columns(nodesOutput)
+
+	table := output.Table{Columns: nodeColumns(keys, *showUsage)}
+	for _, node := range result.Nodes {
+		table.Rows = append(table.Rows, nodeRow(node, keys, usage))
+	}
+	err = printer.Print(table, writer)
 	if err != nil {
-		return fmt.Errorf("columized output: %v", err)
+		return fmt.Errorf("printing output: %v", err)
 	}
-	fmt.Fprintln(writer, output)
 	return nil
 }
 
-func usage(flags *flag.FlagSet, writer io.Writer) {
-	flags.SetOutput(writer)
-	flags.Usage()
-	flags.SetOutput(ioutil.Discard)
+// errOut returns the writer for warnings.
+func (n *NodesCLI) errOut() io.Writer {
+	if n.ErrOut == nil {
+		return os.Stderr
+	}
+	return n.ErrOut
 }
 
-// DefaultClient returns a Kubernetes client based on the given path to a
-// Kubernetes config file.
-func DefaultClient(kubeconfig string) (*kubernetes.Clientset, error) {
-	var loadingRules *clientcmd.ClientConfigLoadingRules
-	if kubeconfig == "" {
-		// Look in the standard places
-		loadingRules = clientcmd.NewDefaultClientConfigLoadingRules()
-	} else {
-		_, err := os.Stat(kubeconfig)
-		if os.IsNotExist(err) {
-			return &kubernetes.Clientset{}, fmt.Errorf("config file: %v", err)
+// nodeUsage returns the usage of each node from the metrics API and sorts the
+// nodes by CPU or memory (the sortBy), highest first. Nodes without metrics
+// are sorted last. If the metrics API is unavailable, a warning is printed to
+// errOut and an empty map is returned.
+func nodeUsage(ctx context.Context, client metricsclientset.Interface, nodes []v1.Node, sortBy string, errOut io.Writer) (map[string]nearby.Usage, error) {
+	nodeNames := []string{}
+	for _, node := range nodes {
+		nodeNames = append(nodeNames, node.Name)
+	}
+	usage, err := nearby.NodeUsage(ctx, client, nodeNames)
+	var unavailable errs.ErrMetricsUnavailable
+	if errors.As(err, &unavailable) {
+		fmt.Fprintf(errOut, "WARNING: %v\n", err)
+		return map[string]nearby.Usage{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not get node usage: %w", err)
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, aOK := usage[nodes[i].Name]
+		b, bOK := usage[nodes[j].Name]
+		if !aOK || !bOK {
+			return aOK && !bOK
 		}
-		// Load from given kubeconfig
-		loadingRules = &clientcmd.ClientConfigLoadingRules{
-			Precedence: []string{kubeconfig},
+		if sortBy == "memory" {
+			return a.Memory.Cmp(b.Memory) > 0
 		}
+		return a.CPU.Cmp(b.CPU) > 0
+	})
+	return usage, nil
+}
+
+// nodeColumns returns the table columns, starting with the given extra
+// columns (e.g. EVENT). The CPU and MEMORY columns are added after AGE when
+// showUsage is true.
+func nodeColumns(keys []string, showUsage bool, extra ...string) []output.Column {
+	columns := []output.Column{}
+	for _, name := range extra {
+		columns = append(columns, output.Column{Name: name})
+	}
+	columns = append(columns,
+		output.Column{Name: "NAME"},
+		output.Column{Name: "STATUS"},
+		output.Column{Name: "ROLES"},
+		output.Column{Name: "AGE"},
+	)
+	if showUsage {
+		columns = append(columns, output.Column{Name: "CPU"}, output.Column{Name: "MEMORY"})
 	}
+	return append(columns,
+		output.Column{Name: "VERSION"},
+		output.Column{Name: topology.ColumnName(keys[0])},
+		output.Column{Name: "INTERNAL-IP", Wide: true},
+		output.Column{Name: "EXTERNAL-IP", Wide: true},
+		output.Column{Name: "OS-IMAGE", Wide: true},
+		output.Column{Name: "KERNEL-VERSION", Wide: true},
+		output.Column{Name: "CONTAINER-RUNTIME", Wide: true},
+	)
+}
 
-	configOverrides := &clientcmd.ConfigOverrides{}
-	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+// nodeRow returns the table row for the node, starting with the cells of the
+// extra columns. The usage cells are added if usage is not nil.
+func nodeRow(node v1.Node, keys []string, usage map[string]nearby.Usage, extra ...string) output.Row {
+	roles := []string{}
+	for key := range node.Labels {
+		if strings.HasPrefix(key, "node-role.kubernetes.io/") {
+			roleParts := strings.Split(key, "/")
+			if len(roleParts) == 2 {
+				roles = append(roles, roleParts[1])
+			}
+		}
+	}
+	var rolesOutput string
+	if len(roles) > 0 {
+		rolesOutput = strings.Join(roles, ",")
+	} else {
+		rolesOutput = "<none>"
+	}
+	nodeValue, _, ok := topology.Value(node.Labels, keys)
+	if !ok {
+		nodeValue = "<unknown>"
+	}
+	age := output.Age(time.Since(node.CreationTimestamp.Time))
+	status := "<unknown>"
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == "Ready" {
+			switch condition.Status {
+			case v1.ConditionTrue:
+				status = "Ready"
+			case v1.ConditionFalse:
+				status = "NotReady"
+			case v1.ConditionUnknown:
+				status = "Unknown"
+			}
+		}
+	}
+	row := output.Row{Object: node.DeepCopy()}
+	row.Cells = append(row.Cells, extra...)
+	row.Cells = append(row.Cells,
+		node.Name,
+		status,
+		rolesOutput,
+		age,
+	)
+	if usage != nil {
+		cpu, memory := "<unknown>", "<unknown>"
+		if nodeUsage, ok := usage[node.Name]; ok {
+			cpu = usageCell(output.CPU(nodeUsage.CPU), nodeUsage.CPU, node.Status.Allocatable[v1.ResourceCPU])
+			memory = usageCell(output.Memory(nodeUsage.Memory), nodeUsage.Memory, node.Status.Allocatable[v1.ResourceMemory])
+		}
+		row.Cells = append(row.Cells, cpu, memory)
+	}
+	row.Cells = append(row.Cells,
+		node.Status.NodeInfo.KubeletVersion,
+		nodeValue,
+		nodeAddress(node, v1.NodeInternalIP),
+		nodeAddress(node, v1.NodeExternalIP),
+		node.Status.NodeInfo.OSImage,
+		node.Status.NodeInfo.KernelVersion,
+		node.Status.NodeInfo.ContainerRuntimeVersion,
+	)
+	return row
+}
 
-	clientConfig, err := kubeConfig.ClientConfig()
-	if err != nil {
-		return &kubernetes.Clientset{}, fmt.Errorf("could not initialize Kubernetes client config: %v", err)
+// usageCell returns the formatted usage followed by its share of the
+// allocatable quantity, e.g. "1500m (37%)", or only the usage if the node
+// reports no allocatable quantity.
+func usageCell(formatted string, used resource.Quantity, allocatable resource.Quantity) string {
+	if allocatable.IsZero() {
+		return formatted
 	}
+	return fmt.Sprintf("%v (%v%%)", formatted, used.MilliValue()*100/allocatable.MilliValue())
+}
 
-	clientset, err := kubernetes.NewForConfig(clientConfig)
-	if err != nil {
-		return &kubernetes.Clientset{}, fmt.Errorf("could not create clientset from config: %v", err)
+// nodeAddress returns the first address of the given type or "<none>".
+func nodeAddress(node v1.Node, addressType v1.NodeAddressType) string {
+	for _, address := range node.Status.Addresses {
+		if address.Type == addressType {
+			return address.Address
+		}
 	}
-	return clientset, nil
+	return "<none>"
+}
+
+// DefaultClient returns a Kubernetes client based on the given path to a
+// Kubernetes config file.
+func DefaultClient(kubeconfig string) (*kubernetes.Clientset, error) {
+	connection := client.Flags{Kubeconfig: kubeconfig}
+	return connection.NewClient()
 }
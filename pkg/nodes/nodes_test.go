@@ -2,6 +2,7 @@ package nodes_test
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path"
@@ -10,9 +11,17 @@ import (
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
 
+	"github.com/leejones/kubectl-nearby/pkg/errs"
 	"github.com/leejones/kubectl-nearby/pkg/nodes"
 )
 
@@ -45,6 +54,20 @@ func TestExecute(t *testing.T) {
 		if !ok {
 			t.Errorf("Expected error type: %T, got: %T\n", got, err)
 		}
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected exit code: %v, got: %v\n", errs.ExitUsage, errs.ExitCode(err))
+		}
+	})
+
+	t.Run("with an unknown node name, returns a not found error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: testclient.NewSimpleClientset(),
+		}
+		err := nodesCLI.Execute([]string{"node-z-1"}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)\n", notFound, err, err)
+		}
 	})
 
 	t.Run("with a node name, returns a list of nodes in the same zone", func(t *testing.T) {
@@ -152,6 +175,405 @@ node-a-2  Ready   <none>  60m  1.19.10  us-east4-a
 	})
 }
 
+func TestExecuteOutputFormats(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-a-1",
+				Labels: map[string]string{
+					"topology.kubernetes.io/zone": "us-east4-a",
+				},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(time.Hour * -1)),
+			},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+				},
+				NodeInfo: v1.NodeSystemInfo{
+					KubeletVersion:          "1.19.10",
+					OSImage:                 "Ubuntu 20.04",
+					KernelVersion:           "5.4.0",
+					ContainerRuntimeVersion: "containerd://1.4.3",
+				},
+			},
+		},
+	)
+
+	var testCases = []struct {
+		args     []string
+		expected string
+	}{
+		{
+			[]string{"node-a-1", "-o", "name"},
+			"node/node-a-1\n",
+		},
+		{
+			[]string{"node-a-1", "-o", "custom-columns=NAME:.metadata.name,IP:.status.addresses[0].address"},
+			"NAME      IP\nnode-a-1  10.0.0.1\n",
+		},
+		{
+			[]string{"node-a-1", "--output=wide"},
+			`NAME      STATUS     ROLES   AGE  VERSION  ZONE        INTERNAL-IP  EXTERNAL-IP  OS-IMAGE      KERNEL-VERSION  CONTAINER-RUNTIME
+node-a-1  <unknown>  <none>  60m  1.19.10  us-east4-a  10.0.0.1     <none>       Ubuntu 20.04  5.4.0           containerd://1.4.3
+`,
+		},
+	}
+	for _, testCase := range testCases {
+		writer := bytes.NewBufferString("")
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute(testCase.args, writer)
+		if err != nil {
+			t.Errorf("Unexpected error for %v: %v\n", testCase.args, err)
+		}
+		if writer.String() != testCase.expected {
+			t.Errorf("Expected output for %v:\n%v\ngot:\n%v\n", testCase.args, testCase.expected, writer.String())
+		}
+	}
+
+	t.Run("with -o json, returns a List of nodes", func(t *testing.T) {
+		writer := bytes.NewBufferString("")
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "-o", "json"}, writer)
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+		for _, expected := range []string{`"kind": "List"`, `"kind": "Node"`, `"name": "node-a-1"`} {
+			if !strings.Contains(writer.String(), expected) {
+				t.Errorf("Expected output to include: %v, got:\n%v", expected, writer.String())
+			}
+		}
+	})
+
+	t.Run("with an unsupported format, returns an error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "-o", "xml"}, bytes.NewBufferString(""))
+		if err == nil {
+			t.Errorf("Expected an error for an unsupported output format")
+		}
+	})
+}
+
+func TestExecuteTopology(t *testing.T) {
+	clientset := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{
+			"topology.kubernetes.io/zone":   "us-east4-a",
+			"topology.kubernetes.io/region": "us-east4",
+			"example.com/rack":              "rack-1",
+		}),
+		testNode("node-a-2", map[string]string{
+			"failure-domain.beta.kubernetes.io/zone":   "us-east4-a",
+			"failure-domain.beta.kubernetes.io/region": "us-east4",
+			"example.com/row":                          "rack-1",
+		}),
+		testNode("node-b-1", map[string]string{
+			"topology.kubernetes.io/zone":   "us-east4-b",
+			"topology.kubernetes.io/region": "us-east4",
+			"example.com/rack":              "rack-2",
+		}),
+		testNode("node-c-1", map[string]string{}),
+	)
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"falls back to the deprecated zone label",
+			[]string{"node-a-2", "-o", "custom-columns=NAME:.metadata.name"},
+			"NAME\nnode-a-1\nnode-a-2\n",
+		},
+		{
+			"with --topology region, returns nodes in the same region",
+			[]string{"node-a-1", "--topology", "region", "-o", "custom-columns=NAME:.metadata.name"},
+			"NAME\nnode-a-1\nnode-a-2\nnode-b-1\n",
+		},
+		{
+			"with --topology-key and the zone label, falls back to the deprecated zone label",
+			[]string{"node-a-2", "--topology-key", "topology.kubernetes.io/zone", "-o", "custom-columns=NAME:.metadata.name"},
+			"NAME\nnode-a-1\nnode-a-2\n",
+		},
+		{
+			"with --topology-key, tries each key in order",
+			[]string{"node-a-2", "--topology-key", "example.com/rack", "--topology-key", "example.com/row", "-o", "custom-columns=NAME:.metadata.name"},
+			"NAME\nnode-a-1\nnode-a-2\n",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			nodesCLI := nodes.NodesCLI{
+				Client: clientset,
+			}
+			err := nodesCLI.Execute(testCase.args, writer)
+			if err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+			}
+			if writer.String() != testCase.expected {
+				t.Errorf("Expected output:\n%v\ngot:\n%v\n", testCase.expected, writer.String())
+			}
+		})
+	}
+
+	t.Run("names the topology column after the label key", func(t *testing.T) {
+		writer := bytes.NewBufferString("")
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "--topology", "example.com/rack"}, writer)
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+		expected := `NAME      STATUS     ROLES   AGE  VERSION  RACK
+node-a-1  <unknown>  <none>  60m  1.19.10  rack-1
+`
+		if writer.String() != expected {
+			t.Errorf("Expected output:\n%v\ngot:\n%v\n", expected, writer.String())
+		}
+	})
+
+	t.Run("with a node missing the topology labels, returns an error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-c-1"}, bytes.NewBufferString(""))
+		var labelMissing errs.ErrTopologyLabelMissing
+		if !errors.As(err, &labelMissing) {
+			t.Errorf("Expected error type: %T, got: %T (%v)\n", labelMissing, err, err)
+		}
+	})
+}
+
+func TestExecutePod(t *testing.T) {
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("working directory: %v", err)
+	}
+	kubeconfig := path.Join(workingDirectory, "../..", "testdata", "test-default-kube-config")
+	clientset := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx-abc123", Namespace: "testing-cluster-default"},
+			Spec:       v1.PodSpec{NodeName: "node-a-2"},
+		},
+	)
+
+	t.Run("with --pod, returns nodes in the same zone as the pod's node", func(t *testing.T) {
+		writer := bytes.NewBufferString("")
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"--pod", "nginx-abc123", "--kubeconfig", kubeconfig, "-o", "custom-columns=NAME:.metadata.name"}, writer)
+		if err != nil {
+			t.Errorf("Unexpected error: %v\n", err)
+		}
+		expected := "NAME\nnode-a-1\nnode-a-2\n"
+		if writer.String() != expected {
+			t.Errorf("Expected output:\n%v\ngot:\n%v\n", expected, writer.String())
+		}
+	})
+
+	t.Run("with an unknown pod, returns a not found error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"--pod", "missing", "--kubeconfig", kubeconfig}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)\n", notFound, err, err)
+		}
+	})
+
+	t.Run("with a node name and --pod, returns a usage error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "--pod", "nginx-abc123"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v\n", err)
+		}
+	})
+
+	t.Run("with --watch and --output yaml, returns a usage error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "--watch", "-o", "yaml"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v\n", err)
+		}
+	})
+}
+
+func TestExecuteLookup(t *testing.T) {
+	node := testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"})
+	node.Spec.ProviderID = "aws:///us-east4-a/i-0123"
+	node.Status.Addresses = []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.2"}}
+	clientset := testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		node,
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+	)
+
+	for _, args := range [][]string{{"--ip", "10.0.0.2"}, {"--provider-id", "aws:///us-east4-a/i-0123"}} {
+		t.Run("with "+args[0]+", returns nodes in the same zone as the node", func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			nodesCLI := nodes.NodesCLI{
+				Client: clientset,
+			}
+			err := nodesCLI.Execute(append(args, "-o", "custom-columns=NAME:.metadata.name"), writer)
+			if err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+			}
+			expected := "NAME\nnode-a-1\nnode-a-2\n"
+			if writer.String() != expected {
+				t.Errorf("Expected output:\n%v\ngot:\n%v\n", expected, writer.String())
+			}
+		})
+	}
+
+	t.Run("with an unknown provider ID, returns a not found error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"--provider-id", "aws:///us-east4-a/i-9999"}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)\n", notFound, err, err)
+		}
+	})
+}
+
+func TestExecuteUsage(t *testing.T) {
+	nodeA1 := testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"})
+	nodeA1.Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("8Gi")}
+	clientset := testclient.NewSimpleClientset(
+		nodeA1,
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-3", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+	)
+	metrics := metricsfake.NewSimpleClientset()
+	for _, nodeMetrics := range []*metricsv1beta1.NodeMetrics{
+		testNodeMetrics("node-a-1", "1500m", "2Gi"),
+		testNodeMetrics("node-a-2", "2", "1Gi"),
+	} {
+		// NewSimpleClientset would guess the wrong resource from the kind.
+		err := metrics.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("nodes"), nodeMetrics, "")
+		if err != nil {
+			t.Fatalf("adding metrics: %v", err)
+		}
+	}
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{
+			"with --usage, shows the usage of each node sorted by CPU",
+			[]string{"node-a-1", "--usage"},
+			[]string{
+				"NAME      STATUS     ROLES   AGE  CPU          MEMORY        VERSION  ZONE",
+				"node-a-2  <unknown>  <none>  60m  2000m        1024Mi        1.19.10  us-east4-a",
+				"node-a-1  <unknown>  <none>  60m  1500m (37%)  2048Mi (25%)  1.19.10  us-east4-a",
+				"node-a-3  <unknown>  <none>  60m  <unknown>    <unknown>     1.19.10  us-east4-a",
+			},
+		},
+		{
+			"with --sort-by memory, sorts the nodes by memory",
+			[]string{"node-a-1", "--usage", "--sort-by", "memory", "-o", "name"},
+			[]string{"node/node-a-1", "node/node-a-2", "node/node-a-3"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			nodesCLI := nodes.NodesCLI{
+				Client:  clientset,
+				Metrics: metrics,
+			}
+			err := nodesCLI.Execute(testCase.args, writer)
+			if err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+			}
+			expected := strings.Join(testCase.expected, "\n") + "\n"
+			if writer.String() != expected {
+				t.Errorf("Expected output:\n%v\ngot:\n%v\n", expected, writer.String())
+			}
+		})
+	}
+
+	t.Run("without metrics-server, shows unknown usage and warns", func(t *testing.T) {
+		unavailable := metricsfake.NewSimpleClientset()
+		unavailable.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "nodes"}, "")
+		})
+		expected := `NAME      STATUS     ROLES   AGE  CPU        MEMORY     VERSION  ZONE
+node-a-1  <unknown>  <none>  60m  <unknown>  <unknown>  1.19.10  us-east4-a
+node-a-2  <unknown>  <none>  60m  <unknown>  <unknown>  1.19.10  us-east4-a
+node-a-3  <unknown>  <none>  60m  <unknown>  <unknown>  1.19.10  us-east4-a
+`
+		expectedWarning := "WARNING: metrics API unavailable (is metrics-server installed?): nodes.metrics.k8s.io \"\" not found\n"
+		writer := bytes.NewBufferString("")
+		errOut := bytes.NewBufferString("")
+		nodesCLI := nodes.NodesCLI{
+			Client:  clientset,
+			Metrics: unavailable,
+			ErrOut:  errOut,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "--usage"}, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+		if expectedWarning != errOut.String() {
+			t.Errorf("Expected warning:\n%v\ngot:\n%v", expectedWarning, errOut.String())
+		}
+	})
+
+	t.Run("with --usage and --watch, returns a usage error", func(t *testing.T) {
+		nodesCLI := nodes.NodesCLI{
+			Client: clientset,
+		}
+		err := nodesCLI.Execute([]string{"node-a-1", "--usage", "--watch"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v\n", err)
+		}
+	})
+}
+
+func testNodeMetrics(name string, cpu string, memory string) *metricsv1beta1.NodeMetrics {
+	return &metricsv1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Usage:      v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)},
+	}
+}
+
+func testNode(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(time.Hour * -1)),
+		},
+		Status: v1.NodeStatus{
+			NodeInfo: v1.NodeSystemInfo{
+				KubeletVersion: "1.19.10",
+			},
+		},
+	}
+}
+
 func TestDefaultClient(t *testing.T) {
 	t.Run("returns a configured Kubernetes client without error", func(t *testing.T) {
 		workingDirectory, err := os.Getwd()
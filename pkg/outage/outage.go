@@ -0,0 +1,184 @@
+// Package outage provides a CLI to simulate the loss of a zone or a node.
+package outage
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+// An OutageCLI is used to create a command line interface for simulating the
+// loss of a zone or a node.
+type OutageCLI struct {
+	Client kubernetes.Interface
+}
+
+// Execute writes which workloads, PodDisruptionBudgets and StatefulSets would
+// be affected by the outage to the given io.Writer and returns an error.
+func (o *OutageCLI) Execute(args []string, writer io.Writer) error {
+	return o.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (o *OutageCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	f := flag.NewFlagSet("kubectl nearby simulate-outage", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Show which workloads would lose all replicas, which PodDisruptionBudgets would be violated and which StatefulSets would lose quorum if a zone or node went down.\n\nUSAGE\n\n  %s simulate-outage --zone ZONE [--region REGION] [OPTIONS]\n  %s simulate-outage --node NODE [OPTIONS]\n\nOPTIONS\n\n", os.Args[0], os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+	var opts nearby.OutageOptions
+	f.StringVar(&opts.Zone, "zone", "", "The zone to simulate the loss of (e.g. us-east4-a)")
+	f.StringVar(&opts.Region, "region", "", "The region of the zone, required if the zone's name is used in several regions")
+	f.StringVar(&opts.Node, "node", "", "The node to simulate the loss of, used instead of a zone")
+	allNamespaces := f.Bool("all-namespaces", false, "Include workloads from all namespaces")
+
+	err := f.Parse(args)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+	if f.NArg() > 0 {
+		return errs.ErrUsage{Err: fmt.Errorf("unexpected arguments: %v", strings.Join(f.Args(), " "))}
+	}
+	if opts.Zone == "" && opts.Node == "" {
+		return errs.ErrUsage{Err: fmt.Errorf("a zone (--zone) or a node (--node) is required")}
+	} else if opts.Zone != "" && opts.Node != "" {
+		return errs.ErrUsage{Err: fmt.Errorf("a zone and a node cannot be given together")}
+	} else if opts.Region != "" && opts.Zone == "" {
+		return errs.ErrUsage{Err: fmt.Errorf("a region (--region) can only be given with a zone (--zone)")}
+	}
+
+	if !*allNamespaces {
+		opts.Namespace, err = connection.CurrentNamespace()
+		if err != nil {
+			return err
+		}
+	}
+	if o.Client == nil {
+		o.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := nearby.SimulateOutage(ctx, o.Client, opts)
+	if err != nil {
+		return err
+	}
+
+	lost := "node " + opts.Node
+	if opts.Zone != "" {
+		zone := opts.Zone
+		if opts.Region != "" {
+			zone += " in region " + opts.Region
+		}
+		lost = fmt.Sprintf("zone %v (%v nodes: %v)", zone, len(result.Nodes), strings.Join(result.Nodes, ", "))
+	}
+	sections := []string{fmt.Sprintf("Simulating an outage of %v", lost)}
+
+	if len(result.Workloads) == 0 {
+		sections = append(sections, "No workloads have pods on the lost nodes.")
+	} else {
+		rows := [][]string{{"NAMESPACE", "WORKLOAD", "PODS", "LOST", "REMAINING", "STATUS"}}
+		for _, impact := range result.Workloads {
+			status := "DEGRADED"
+			if impact.Lost == impact.Pods {
+				status = "ALL REPLICAS LOST"
+			}
+			rows = append(rows, []string{
+				impact.Workload.Namespace,
+				strings.ToLower(impact.Workload.Kind) + "/" + impact.Workload.Name,
+				strconv.Itoa(impact.Pods),
+				strconv.Itoa(impact.Lost),
+				strconv.Itoa(impact.Pods - impact.Lost),
+				status,
+			})
+		}
+		section, err := titledTable("WORKLOADS", rows)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, section)
+	}
+
+	if len(result.DisruptionBudgets) > 0 {
+		rows := [][]string{{"NAMESPACE", "NAME", "HEALTHY", "MIN-HEALTHY", "REMAINING", "STATUS"}}
+		for _, impact := range result.DisruptionBudgets {
+			status := "OK"
+			if impact.Violated() {
+				status = "VIOLATED"
+			}
+			rows = append(rows, []string{
+				impact.Namespace,
+				impact.Name,
+				strconv.Itoa(impact.Healthy),
+				strconv.Itoa(impact.DesiredHealthy),
+				strconv.Itoa(impact.Remaining),
+				status,
+			})
+		}
+		section, err := titledTable("POD DISRUPTION BUDGETS", rows)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, section)
+	}
+
+	if len(result.StatefulSets) > 0 {
+		rows := [][]string{{"NAMESPACE", "NAME", "REPLICAS", "QUORUM", "REMAINING", "STATUS"}}
+		for _, impact := range result.StatefulSets {
+			status := "OK"
+			if impact.Lost() {
+				status = "QUORUM LOST"
+			}
+			rows = append(rows, []string{
+				impact.Namespace,
+				impact.Name,
+				strconv.Itoa(impact.Replicas),
+				strconv.Itoa(impact.Quorum()),
+				strconv.Itoa(impact.Remaining),
+				status,
+			})
+		}
+		section, err := titledTable("STATEFULSET QUORUM", rows)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, section)
+	}
+
+	_, err = fmt.Fprintln(writer, strings.Join(sections, "\n\n"))
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return nil
+}
+
+// titledTable returns the rows as a table under the title.
+func titledTable(title string, rows [][]string) (string, error) {
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return "", fmt.Errorf("printing output: %v", err)
+	}
+	return title + "\n" + strings.TrimRight(formatted, "\n"), nil
+}
@@ -0,0 +1,140 @@
+package outage_test
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/outage"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	t.Run("with a zone, shows the impact of losing it", func(t *testing.T) {
+		expected := `Simulating an outage of zone us-east4-a (2 nodes: node-a-1, node-a-2)
+
+WORKLOADS
+NAMESPACE                WORKLOAD        PODS  LOST  REMAINING  STATUS
+testing-cluster-default  pod/debug       1     1     0          ALL REPLICAS LOST
+testing-cluster-default  statefulset/zk  3     2     1          DEGRADED
+
+POD DISRUPTION BUDGETS
+NAMESPACE                NAME  HEALTHY  MIN-HEALTHY  REMAINING  STATUS
+testing-cluster-default  zk    3        2            1          VIOLATED
+
+STATEFULSET QUORUM
+NAMESPACE                NAME  REPLICAS  QUORUM  REMAINING  STATUS
+testing-cluster-default  zk    3         2       1          QUORUM LOST
+`
+		got, err := execute([]string{"--zone", "us-east4-a"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with a node, shows the impact of losing it", func(t *testing.T) {
+		expected := `Simulating an outage of node node-b-1
+
+WORKLOADS
+NAMESPACE                WORKLOAD        PODS  LOST  REMAINING  STATUS
+testing-cluster-default  statefulset/zk  3     1     2          DEGRADED
+
+POD DISRUPTION BUDGETS
+NAMESPACE                NAME  HEALTHY  MIN-HEALTHY  REMAINING  STATUS
+testing-cluster-default  zk    3        2            2          OK
+
+STATEFULSET QUORUM
+NAMESPACE                NAME  REPLICAS  QUORUM  REMAINING  STATUS
+testing-cluster-default  zk    3         2       2          OK
+`
+		got, err := execute([]string{"--node", "node-b-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with a node without pods, says so", func(t *testing.T) {
+		expected := `Simulating an outage of node node-c-1
+
+No workloads have pods on the lost nodes.
+`
+		got, err := execute([]string{"--node", "node-c-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an unknown node, returns not found", func(t *testing.T) {
+		_, err := execute([]string{"--node", "node-z-1"})
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+
+	var usageCases = []struct {
+		name string
+		args []string
+	}{
+		{"with no zone or node", []string{}},
+		{"with a zone and a node", []string{"--zone", "us-east4-a", "--node", "node-a-1"}},
+		{"with a region and a node", []string{"--region", "us-east4", "--node", "node-a-1"}},
+		{"with an argument", []string{"us-east4-a"}},
+	}
+	for _, testCase := range usageCases {
+		t.Run(testCase.name+", returns a usage error", func(t *testing.T) {
+			_, err := execute(testCase.args)
+			if errs.ExitCode(err) != errs.ExitUsage {
+				t.Errorf("Expected a usage error, got: %v", err)
+			}
+		})
+	}
+}
+
+func execute(args []string) (string, error) {
+	return clienttest.Execute(&outage.OutageCLI{Client: testClient()}, args)
+}
+
+// testClient returns a fake client with the StatefulSet zk spread over two
+// zones, guarded by a PodDisruptionBudget, and a bare pod in zone us-east4-a.
+func testClient() kubernetes.Interface {
+	replicas := int32(3)
+	minAvailable := intstr.FromInt32(2)
+	return testclient.NewSimpleClientset(
+		clienttest.Node("node-a-1", clienttest.TopologyLabels("us-east4-a", "")),
+		clienttest.Node("node-a-2", clienttest.TopologyLabels("us-east4-a", "")),
+		clienttest.Node("node-b-1", clienttest.TopologyLabels("us-east4-b", "")),
+		clienttest.Node("node-c-1", clienttest.TopologyLabels("us-east4-c", "")),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "zk", Namespace: clienttest.Namespace, UID: "zk"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "zk", Namespace: clienttest.Namespace},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "zk"}},
+			},
+		},
+		clienttest.OwnedPod("zk-0", "node-a-1", "StatefulSet", "zk"),
+		clienttest.OwnedPod("zk-1", "node-a-2", "StatefulSet", "zk"),
+		clienttest.OwnedPod("zk-2", "node-b-1", "StatefulSet", "zk"),
+		clienttest.Pod("debug", "node-a-1", nil),
+	)
+}
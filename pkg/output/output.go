@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func Age(duration time.Duration) string {
@@ -20,6 +22,40 @@ func Age(duration time.Duration) string {
 	}
 }
 
+// CPU formats a CPU quantity in millicores, like kubectl top (e.g. 250m).
+func CPU(quantity resource.Quantity) string {
+	return fmt.Sprintf("%vm", quantity.MilliValue())
+}
+
+// Memory formats a memory quantity in mebibytes, like kubectl top (e.g.
+// 128Mi).
+func Memory(quantity resource.Quantity) string {
+	return fmt.Sprintf("%vMi", quantity.Value()/(1024*1024))
+}
+
+// NoneIfEmpty returns the value, or <none> if it is empty.
+func NoneIfEmpty(value string) string {
+	return ifEmpty(value, "<none>")
+}
+
+// UnknownIfEmpty returns the value, or <unknown> if it is empty.
+func UnknownIfEmpty(value string) string {
+	return ifEmpty(value, "<unknown>")
+}
+
+// DashIfEmpty returns the value, or - if it is empty (e.g. for a list of
+// flags).
+func DashIfEmpty(value string) string {
+	return ifEmpty(value, "-")
+}
+
+func ifEmpty(value string, placeholder string) string {
+	if value == "" {
+		return placeholder
+	}
+	return value
+}
+
 func Columns(rows [][]string) (string, error) {
 	columnLengths := []int{}
 	columnCount := len(rows[0])
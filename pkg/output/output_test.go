@@ -5,6 +5,8 @@ import (
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/resource"
+
 	"github.com/leejones/kubectl-nearby/pkg/output"
 )
 
@@ -40,6 +42,48 @@ func TestAge(t *testing.T) {
 	}
 }
 
+func TestUsage(t *testing.T) {
+	var testCases = []struct {
+		format func(resource.Quantity) string
+		input  string
+		output string
+	}{
+		{output.CPU, "1500m", "1500m"},
+		{output.CPU, "2", "2000m"},
+		{output.CPU, "123456n", "1m"},
+		{output.Memory, "128Mi", "128Mi"},
+		{output.Memory, "1Gi", "1024Mi"},
+		{output.Memory, "204800Ki", "200Mi"},
+	}
+	for _, testCase := range testCases {
+		got := testCase.format(resource.MustParse(testCase.input))
+		if got != testCase.output {
+			t.Errorf("Expected %v to be formatted as: %v, got: %v", testCase.input, testCase.output, got)
+		}
+	}
+}
+
+func TestIfEmpty(t *testing.T) {
+	var testCases = []struct {
+		format func(string) string
+		input  string
+		output string
+	}{
+		{output.NoneIfEmpty, "", "<none>"},
+		{output.NoneIfEmpty, "10.0.0.1", "10.0.0.1"},
+		{output.UnknownIfEmpty, "", "<unknown>"},
+		{output.UnknownIfEmpty, "us-east4-a", "us-east4-a"},
+		{output.DashIfEmpty, "", "-"},
+		{output.DashIfEmpty, "DAEMONSET", "DAEMONSET"},
+	}
+	for _, testCase := range testCases {
+		got := testCase.format(testCase.input)
+		if got != testCase.output {
+			t.Errorf("Expected %q to be formatted as: %v, got: %v", testCase.input, testCase.output, got)
+		}
+	}
+}
+
 func TestColumns(t *testing.T) {
 	want := strings.Trim(`
 NAMESPACE   NAME               READY
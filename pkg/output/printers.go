@@ -0,0 +1,277 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+// Formats lists the output formats accepted by NewPrinter.
+var Formats = []string{"json", "yaml", "wide", "name", "custom-columns=...", "go-template=...", "jsonpath=..."}
+
+// A Column describes a single column of a Table.
+type Column struct {
+	Name string
+	// Wide columns are only shown with the wide output format.
+	Wide bool
+}
+
+// A Row holds the cells for a single object in a Table.
+type Row struct {
+	Cells  []string
+	Object runtime.Object
+}
+
+// A Table is a list of objects along with their tabular representation. Each
+// row must have one cell per column.
+type Table struct {
+	Columns []Column
+	Rows    []Row
+}
+
+// A Printer writes a Table to an io.Writer in a specific format.
+type Printer interface {
+	Print(table Table, writer io.Writer) error
+}
+
+// NewPrinter returns a Printer for the given output format. An empty format
+// returns the default table printer.
+func NewPrinter(format string) (Printer, error) {
+	switch format {
+	case "":
+		return &TablePrinter{}, nil
+	case "wide":
+		return &TablePrinter{Wide: true}, nil
+	case "json":
+		return &JSONPrinter{}, nil
+	case "yaml":
+		return &YAMLPrinter{}, nil
+	case "name":
+		return &NamePrinter{}, nil
+	}
+	name, argument, _ := strings.Cut(format, "=")
+	switch name {
+	case "jsonpath":
+		return NewJSONPathPrinter(argument)
+	case "go-template":
+		return NewGoTemplatePrinter(argument)
+	case "custom-columns":
+		return NewCustomColumnsPrinter(argument)
+	}
+	return nil, fmt.Errorf("unsupported output format: %q (allowed formats: %s)", format, strings.Join(Formats, ", "))
+}
+
+// A TablePrinter prints a Table as padded text columns.
+type TablePrinter struct {
+	Wide bool
+}
+
+// Print writes the table's columns and rows to the writer.
+func (p *TablePrinter) Print(table Table, writer io.Writer) error {
+	rows, err := visibleCells(table, p.Wide)
+	if err != nil {
+		return err
+	}
+	formatted, err := Columns(rows)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, formatted)
+	return err
+}
+
+// visibleCells returns the header and the cells of each row, leaving out wide
+// columns unless wide is true.
+func visibleCells(table Table, wide bool) ([][]string, error) {
+	visible := []int{}
+	header := []string{}
+	for index, column := range table.Columns {
+		if column.Wide && !wide {
+			continue
+		}
+		visible = append(visible, index)
+		header = append(header, column.Name)
+	}
+
+	rows := [][]string{header}
+	for _, row := range table.Rows {
+		if len(row.Cells) != len(table.Columns) {
+			return nil, fmt.Errorf("row has %v cells, expected %v", len(row.Cells), len(table.Columns))
+		}
+		cells := []string{}
+		for _, index := range visible {
+			cells = append(cells, row.Cells[index])
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// NewStreamPrinter returns a Printer for tables printed one after another as
+// parts of a single stream (e.g. --watch events). Tables are printed with the
+// header only once and keep the column widths of the first table where
+// possible. Only the table formats are supported: the other formats would
+// print each table as a separate List, without its EVENT column.
+func NewStreamPrinter(format string) (Printer, error) {
+	printer, err := NewPrinter(format)
+	if err != nil {
+		return nil, err
+	}
+	tablePrinter, ok := printer.(*TablePrinter)
+	if !ok {
+		return nil, fmt.Errorf("output format %q cannot be used with --watch (allowed formats: wide)", format)
+	}
+	return &streamTablePrinter{wide: tablePrinter.Wide}, nil
+}
+
+type streamTablePrinter struct {
+	wide   bool
+	widths []int
+}
+
+func (p *streamTablePrinter) Print(table Table, writer io.Writer) error {
+	rows, err := visibleCells(table, p.wide)
+	if err != nil {
+		return err
+	}
+	if p.widths != nil {
+		rows = rows[1:]
+	} else {
+		p.widths = make([]int, len(rows[0]))
+	}
+	for _, row := range rows {
+		for index, cell := range row {
+			if len(cell) > p.widths[index] {
+				p.widths[index] = len(cell)
+			}
+		}
+	}
+	for _, row := range rows {
+		cells := []string{}
+		for index, cell := range row {
+			// Right pad all columns except the last one.
+			if index != len(row)-1 {
+				cell = fmt.Sprintf("%-*s", p.widths[index], cell)
+			}
+			cells = append(cells, cell)
+		}
+		if _, err := fmt.Fprintln(writer, strings.Join(cells, "  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A JSONPrinter prints the table's objects as a JSON v1 List.
+type JSONPrinter struct{}
+
+// Print writes the table's objects to the writer.
+func (p *JSONPrinter) Print(table Table, writer io.Writer) error {
+	data, err := listJSON(table)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, string(data))
+	return err
+}
+
+// A YAMLPrinter prints the table's objects as a YAML v1 List.
+type YAMLPrinter struct{}
+
+// Print writes the table's objects to the writer.
+func (p *YAMLPrinter) Print(table Table, writer io.Writer) error {
+	data, err := listJSON(table)
+	if err != nil {
+		return err
+	}
+	data, err = yaml.JSONToYAML(data)
+	if err != nil {
+		return fmt.Errorf("converting to YAML: %v", err)
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// A NamePrinter prints one KIND/NAME (or KIND/NAMESPACE/NAME for namespaced
+// objects) line per object.
+type NamePrinter struct{}
+
+// Print writes the name of each of the table's objects to the writer.
+func (p *NamePrinter) Print(table Table, writer io.Writer) error {
+	for _, row := range table.Rows {
+		object, err := versioned(row.Object)
+		if err != nil {
+			return err
+		}
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			return fmt.Errorf("object metadata: %v", err)
+		}
+		kind := strings.ToLower(object.GetObjectKind().GroupVersionKind().Kind)
+		name := accessor.GetName()
+		if accessor.GetNamespace() != "" {
+			name = accessor.GetNamespace() + "/" + name
+		}
+		if _, err := fmt.Fprintf(writer, "%s/%s\n", kind, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the table's objects wrapped in a v1 List with the kind and API
+// version set on every item.
+func List(table Table) (*metav1.List, error) {
+	list := &metav1.List{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "List",
+			APIVersion: "v1",
+		},
+		Items: []runtime.RawExtension{},
+	}
+	for _, row := range table.Rows {
+		object, err := versioned(row.Object)
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Object: object})
+	}
+	return list, nil
+}
+
+func listJSON(table Table) ([]byte, error) {
+	list, err := List(table)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(list, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("converting to JSON: %v", err)
+	}
+	return data, nil
+}
+
+// versioned returns a copy of the object with its kind and API version set.
+// Objects returned by the API client usually have an empty TypeMeta.
+func versioned(object runtime.Object) (runtime.Object, error) {
+	if object == nil {
+		return nil, fmt.Errorf("row has no object")
+	}
+	object = object.DeepCopyObject()
+	if !object.GetObjectKind().GroupVersionKind().Empty() {
+		return object, nil
+	}
+	kinds, _, err := scheme.Scheme.ObjectKinds(object)
+	if err != nil {
+		return nil, fmt.Errorf("object kind: %v", err)
+	}
+	object.GetObjectKind().SetGroupVersionKind(kinds[0])
+	return object, nil
+}
@@ -0,0 +1,235 @@
+package output_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+func testTable() output.Table {
+	return output.Table{
+		Columns: []output.Column{
+			{Name: "NAMESPACE"},
+			{Name: "NAME"},
+			{Name: "NODE", Wide: true},
+		},
+		Rows: []output.Row{
+			{
+				Cells: []string{"default", "foo-abc123", "node-a-1"},
+				Object: &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "foo-abc123", Namespace: "default"},
+					Spec:       v1.PodSpec{NodeName: "node-a-1"},
+				},
+			},
+			{
+				Cells: []string{"production", "bar-def456", "node-a-1"},
+				Object: &v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "bar-def456", Namespace: "production"},
+					Spec:       v1.PodSpec{NodeName: "node-a-1"},
+				},
+			},
+		},
+	}
+}
+
+func printTable(t *testing.T, format string, table output.Table) string {
+	t.Helper()
+	printer, err := output.NewPrinter(format)
+	if err != nil {
+		t.Fatalf("Unexpected error creating printer: %v", err)
+	}
+	writer := bytes.NewBufferString("")
+	err = printer.Print(table, writer)
+	if err != nil {
+		t.Fatalf("Unexpected error printing: %v", err)
+	}
+	return writer.String()
+}
+
+func TestNewPrinter(t *testing.T) {
+	t.Run("default format hides wide columns", func(t *testing.T) {
+		want := `NAMESPACE   NAME
+default     foo-abc123
+production  bar-def456
+`
+		got := printTable(t, "", testTable())
+		if want != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", want, got)
+		}
+	})
+
+	t.Run("wide format shows wide columns", func(t *testing.T) {
+		want := `NAMESPACE   NAME        NODE
+default     foo-abc123  node-a-1
+production  bar-def456  node-a-1
+`
+		got := printTable(t, "wide", testTable())
+		if want != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", want, got)
+		}
+	})
+
+	t.Run("name format prints kind, namespace and name", func(t *testing.T) {
+		want := "pod/default/foo-abc123\npod/production/bar-def456\n"
+		got := printTable(t, "name", testTable())
+		if want != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", want, got)
+		}
+	})
+
+	t.Run("name format omits the namespace of cluster scoped objects", func(t *testing.T) {
+		table := output.Table{
+			Columns: []output.Column{{Name: "NAME"}},
+			Rows: []output.Row{
+				{Cells: []string{"node-a-1"}, Object: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a-1"}}},
+			},
+		}
+		want := "node/node-a-1\n"
+		got := printTable(t, "name", table)
+		if want != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", want, got)
+		}
+	})
+
+	t.Run("json format prints a versioned list", func(t *testing.T) {
+		got := printTable(t, "json", testTable())
+		list := struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+			Items      []v1.Pod
+		}{}
+		err := json.Unmarshal([]byte(got), &list)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing JSON output: %v\n%v", err, got)
+		}
+		if list.APIVersion != "v1" || list.Kind != "List" {
+			t.Errorf("Expected a v1 List, got: %v %v", list.APIVersion, list.Kind)
+		}
+		if len(list.Items) != 2 {
+			t.Fatalf("Expected 2 items, got: %v", len(list.Items))
+		}
+		if list.Items[0].Kind != "Pod" || list.Items[0].APIVersion != "v1" {
+			t.Errorf("Expected items to be v1 Pods, got: %v %v", list.Items[0].APIVersion, list.Items[0].Kind)
+		}
+		if list.Items[1].Name != "bar-def456" {
+			t.Errorf("Expected second item to be bar-def456, got: %v", list.Items[1].Name)
+		}
+	})
+
+	t.Run("yaml format prints a versioned list", func(t *testing.T) {
+		got := printTable(t, "yaml", testTable())
+		for _, expected := range []string{"apiVersion: v1\n", "kind: List\n", "  kind: Pod\n", "    name: foo-abc123\n"} {
+			if !strings.Contains(got, expected) {
+				t.Errorf("Expected YAML output to include %q, got:\n%v", expected, got)
+			}
+		}
+	})
+
+	t.Run("unsupported format returns an error", func(t *testing.T) {
+		_, err := output.NewPrinter("xml")
+		if err == nil {
+			t.Errorf("Expected an error for an unsupported format")
+		}
+	})
+}
+
+func TestTemplatePrinters(t *testing.T) {
+	var testCases = []struct {
+		format string
+		want   string
+	}{
+		{
+			"jsonpath={.items[*].metadata.name}",
+			"foo-abc123 bar-def456",
+		},
+		{
+			`jsonpath={range .items[*]}{.metadata.namespace}/{.spec.nodeName}{"\n"}{end}`,
+			"default/node-a-1\nproduction/node-a-1\n",
+		},
+		{
+			"go-template={{range .items}}{{.metadata.name}}:{{.kind}} {{end}}",
+			"foo-abc123:Pod bar-def456:Pod ",
+		},
+		{
+			"custom-columns=NAME:.metadata.name,NODE:.spec.nodeName,IP:status.podIP",
+			"NAME        NODE      IP\nfoo-abc123  node-a-1  <none>\nbar-def456  node-a-1  <none>\n",
+		},
+		{
+			"custom-columns=NAMESPACE:{.metadata.namespace}",
+			"NAMESPACE\ndefault\nproduction\n",
+		},
+	}
+	for _, testCase := range testCases {
+		got := printTable(t, testCase.format, testTable())
+		if testCase.want != got {
+			t.Errorf("Expected output for %v:\n%v\ngot:\n%v", testCase.format, testCase.want, got)
+		}
+	}
+
+	t.Run("large integers are not printed as floats", func(t *testing.T) {
+		gracePeriod := int64(1000000)
+		table := testTable()
+		table.Rows[0].Object.(*v1.Pod).Spec.TerminationGracePeriodSeconds = &gracePeriod
+		for format, want := range map[string]string{
+			"jsonpath={.items[0].spec.terminationGracePeriodSeconds}":                      "1000000",
+			"go-template={{(index .items 0).spec.terminationGracePeriodSeconds}}":          "1000000",
+			"custom-columns=NAME:.metadata.name,GRACE:.spec.terminationGracePeriodSeconds": "NAME        GRACE\nfoo-abc123  1000000\nbar-def456  <none>\n",
+		} {
+			got := printTable(t, format, table)
+			if want != got {
+				t.Errorf("Expected output for %v:\n%v\ngot:\n%v", format, want, got)
+			}
+		}
+	})
+
+	for _, format := range []string{"jsonpath={.items[", "go-template={{.items", "custom-columns=", "custom-columns=NAME"} {
+		_, err := output.NewPrinter(format)
+		if err == nil {
+			t.Errorf("Expected an error for invalid format: %v", format)
+		}
+	}
+}
+
+func TestNewStreamPrinter(t *testing.T) {
+	t.Run("table format prints the header once and keeps column widths", func(t *testing.T) {
+		printer, err := output.NewStreamPrinter("")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		writer := bytes.NewBufferString("")
+		table := testTable()
+		err = printer.Print(table, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		table.Rows = table.Rows[:1]
+		table.Rows[0].Cells = []string{"kube-system", "baz", "node-a-2"}
+		err = printer.Print(table, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		expected := `NAMESPACE   NAME
+default     foo-abc123
+production  bar-def456
+kube-system  baz
+`
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+	})
+
+	t.Run("other formats return an error", func(t *testing.T) {
+		for _, format := range []string{"json", "yaml", "name", "jsonpath={.items[*].metadata.name}", "go-template={{.kind}}", "custom-columns=NAME:.metadata.name"} {
+			_, err := output.NewStreamPrinter(format)
+			if err == nil {
+				t.Errorf("Expected an error for format: %v", format)
+			}
+		}
+	})
+}
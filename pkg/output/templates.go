@@ -0,0 +1,194 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// A JSONPathPrinter prints the result of a JSONPath template executed against
+// the table's objects as a v1 List (e.g. {.items[*].metadata.name}).
+type JSONPathPrinter struct {
+	template *jsonpath.JSONPath
+}
+
+// NewJSONPathPrinter parses the given JSONPath template.
+func NewJSONPathPrinter(text string) (*JSONPathPrinter, error) {
+	template := jsonpath.New("output").AllowMissingKeys(true)
+	err := template.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing jsonpath %q: %v", text, err)
+	}
+	return &JSONPathPrinter{template: template}, nil
+}
+
+// Print writes the result of the template to the writer.
+func (p *JSONPathPrinter) Print(table Table, writer io.Writer) error {
+	list, err := unstructuredList(table)
+	if err != nil {
+		return err
+	}
+	err = p.template.Execute(writer, list)
+	if err != nil {
+		return fmt.Errorf("executing jsonpath: %v", err)
+	}
+	return nil
+}
+
+// A GoTemplatePrinter prints the result of a Go template executed against the
+// table's objects as a v1 List (e.g. {{range .items}}{{.metadata.name}}{{end}}).
+type GoTemplatePrinter struct {
+	template *template.Template
+}
+
+// NewGoTemplatePrinter parses the given Go template.
+func NewGoTemplatePrinter(text string) (*GoTemplatePrinter, error) {
+	template, err := template.New("output").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing go-template: %v", err)
+	}
+	return &GoTemplatePrinter{template: template}, nil
+}
+
+// Print writes the result of the template to the writer.
+func (p *GoTemplatePrinter) Print(table Table, writer io.Writer) error {
+	list, err := unstructuredList(table)
+	if err != nil {
+		return err
+	}
+	err = p.template.Execute(writer, list)
+	if err != nil {
+		return fmt.Errorf("executing go-template: %v", err)
+	}
+	return nil
+}
+
+type customColumn struct {
+	header   string
+	template *jsonpath.JSONPath
+}
+
+// A CustomColumnsPrinter prints a table with user defined columns. Each column
+// is a HEADER:JSONPATH pair evaluated against each object.
+type CustomColumnsPrinter struct {
+	columns []customColumn
+}
+
+// NewCustomColumnsPrinter parses a comma separated list of HEADER:JSONPATH
+// pairs (e.g. NAME:.metadata.name,NODE:.spec.nodeName).
+func NewCustomColumnsPrinter(spec string) (*CustomColumnsPrinter, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("custom-columns format requires at least one HEADER:JSONPATH column")
+	}
+	printer := &CustomColumnsPrinter{}
+	for _, part := range strings.Split(spec, ",") {
+		header, expression, ok := strings.Cut(part, ":")
+		if !ok || header == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected HEADER:JSONPATH", part)
+		}
+		template := jsonpath.New(header).AllowMissingKeys(true)
+		err := template.Parse(relaxedJSONPath(expression))
+		if err != nil {
+			return nil, fmt.Errorf("parsing custom column %q: %v", part, err)
+		}
+		printer.columns = append(printer.columns, customColumn{header: header, template: template})
+	}
+	return printer, nil
+}
+
+// Print writes the custom columns for each of the table's objects to the
+// writer.
+func (p *CustomColumnsPrinter) Print(table Table, writer io.Writer) error {
+	rows := [][]string{{}}
+	for _, column := range p.columns {
+		rows[0] = append(rows[0], column.header)
+	}
+	for _, row := range table.Rows {
+		object, err := unstructured(row)
+		if err != nil {
+			return err
+		}
+		cells := []string{}
+		for _, column := range p.columns {
+			results, err := column.template.FindResults(object)
+			if err != nil {
+				return fmt.Errorf("evaluating column %v: %v", column.header, err)
+			}
+			values := []string{}
+			for _, result := range results {
+				for _, value := range result {
+					values = append(values, fmt.Sprintf("%v", value.Interface()))
+				}
+			}
+			if len(values) == 0 {
+				cells = append(cells, "<none>")
+			} else {
+				cells = append(cells, strings.Join(values, ","))
+			}
+		}
+		rows = append(rows, cells)
+	}
+	formatted, err := Columns(rows)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, formatted)
+	return err
+}
+
+var relaxedJSONPathMatcher = regexp.MustCompile(`^\{?(\.?[^{}]*)\}?$`)
+
+// relaxedJSONPath accepts expressions with or without the surrounding braces
+// and leading dot, like kubectl's custom-columns (e.g. metadata.name).
+func relaxedJSONPath(expression string) string {
+	matches := relaxedJSONPathMatcher.FindStringSubmatch(expression)
+	if matches == nil {
+		return expression
+	}
+	path := matches[1]
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// unstructuredList returns the table's objects as a generic v1 List so
+// templates can refer to fields by their JSON names.
+func unstructuredList(table Table) (interface{}, error) {
+	data, err := listJSON(table)
+	if err != nil {
+		return nil, err
+	}
+	return fromJSON(data)
+}
+
+func unstructured(row Row) (interface{}, error) {
+	object, err := versioned(row.Object)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, fmt.Errorf("converting to JSON: %v", err)
+	}
+	return fromJSON(data)
+}
+
+// fromJSON decodes the data into generic values, keeping numbers as
+// json.Number so large integers aren't printed as floats (e.g. 1e+06).
+func fromJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var result interface{}
+	err := decoder.Decode(&result)
+	if err != nil {
+		return nil, fmt.Errorf("converting from JSON: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,725 @@
+// Package pods provides a CLI to list nearby pods.
+package pods
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// A PodsCLI is used to create a command line interface for listing nearby
+// pods.
+type PodsCLI struct {
+	Client kubernetes.Interface
+	// Clients are used instead of clients built from the kubeconfig for the
+	// contexts given with --contexts or --all-contexts, by context name.
+	Clients map[string]kubernetes.Interface
+	// Metrics is used for --usage instead of a client built from the
+	// kubeconfig.
+	Metrics metricsclientset.Interface
+	// ErrOut receives warnings, such as unscheduled targets or the metrics
+	// API being unavailable. It defaults to os.Stderr.
+	ErrOut io.Writer
+}
+
+// ErrPodNameRequired is returned when no pod name, workload, selector, node,
+// IP or container ID is given.
+type ErrPodNameRequired struct{}
+
+func (err ErrPodNameRequired) Error() string {
+	return "a pod name, selector, node, IP or container ID is required"
+}
+
+func (err ErrPodNameRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// options holds the parsed command line arguments.
+type options struct {
+	allNamespaces bool
+	connection    client.Flags
+	containerID   string
+	ip            string
+	namespace     string
+	nodeName      string
+	output        string
+	podName       string
+	resources     bool
+	risk          bool
+	selector      string
+	sortBy        string
+	topology      string
+	usage         bool
+	watch         bool
+	workloadKind  string
+	workloadName  string
+}
+
+// contextPods holds the nearby pods found in a kubeconfig context.
+type contextPods struct {
+	context string
+	pods    []podInfo
+	// warnings are printed once the pods of every context are fetched.
+	warnings []string
+}
+
+type podInfo struct {
+	age                  string
+	containersCount      int
+	containersReadyCount int
+	ip                   string
+	name                 string
+	namespace            string
+	near                 []string
+	nodeName             string
+	nominatedNodeName    string
+	pod                  *v1.Pod
+	restartCount         int32
+	risk                 *nearby.Risk
+	status               string
+	usage                *nearby.Usage
+}
+
+// Execute writes a list of nearby pods to the given io.Writer and returns an
+// error.
+func (p *PodsCLI) Execute(args []string, writer io.Writer) error {
+	return p.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (p *PodsCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	opts := options{}
+	var remainingArgs []string
+
+	if len(args) > 0 {
+		matched, err := regexp.MatchString("^-", args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing arguments")
+		}
+		remainingArgs = args
+		if !matched {
+			kind, name, err := nearby.ParseTarget(args[0])
+			if err != nil {
+				return err
+			}
+			if kind == nearby.KindPod {
+				opts.podName = name
+			} else {
+				opts.workloadKind = kind
+				opts.workloadName = name
+			}
+			if len(args) > 1 {
+				remainingArgs = args[1:]
+			} else {
+				remainingArgs = []string{}
+			}
+		}
+	}
+
+	f := flag.NewFlagSet("kubectl nearby pods", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "List pods on the same node.\n\nUSAGE\n\n  %s pods POD [OPTIONS]\n  %s pods TYPE/NAME [OPTIONS]\n  %s pods -l SELECTOR [OPTIONS]\n  %s pods --node NODE [OPTIONS]\n  %s pods --ip IP [OPTIONS]\n  %s pods --container-id ID [OPTIONS]\n\nTYPE is one of: deploy, sts, ds, job, rs.\n\nOPTIONS\n\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	f.BoolVar(&opts.allNamespaces, "all-namespaces", false, "Show colocated pods from all namespaces")
+	opts.connection.AddFlags(f)
+	opts.connection.AddContextsFlags(f)
+	f.StringVar(&opts.containerID, "container-id", "", "List pods near the pod with the container ID (e.g. containerd://0123abcd, the runtime prefix is optional and the ID may be shortened), used instead of a pod name")
+	f.StringVar(&opts.ip, "ip", "", "List pods near the pod with the IP, used instead of a pod name")
+	f.StringVar(&opts.nodeName, "node", "", "List pods on the node (or near it with --topology), used instead of a pod name")
+	f.StringVar(&opts.output, "output", "", fmt.Sprintf("Output format. One of: %s", strings.Join(output.Formats, ", ")))
+	f.StringVar(&opts.output, "o", "", "Shorthand for --output")
+	f.BoolVar(&opts.resources, "resources", false, "Show the CPU, memory and ephemeral storage requests and limits of each pod and the total of its node, as a share of the node's allocatable resources")
+	f.StringVar(&opts.selector, "selector", "", "Label selector for the target pods (e.g. app=checkout), used instead of a pod name")
+	f.StringVar(&opts.selector, "l", "", "Shorthand for --selector")
+	f.BoolVar(&opts.watch, "watch", false, "After listing the pods, watch for pods arriving on or leaving the nodes (stop with Ctrl-C)")
+	f.BoolVar(&opts.watch, "w", false, "Shorthand for --watch")
+	f.BoolVar(&opts.usage, "usage", false, "Show the CPU and memory used by each pod, from metrics-server, sorted by CPU")
+	f.BoolVar(&opts.risk, "risk", false, "Score each pod by how likely it is to disturb its neighbors (QoS class, limits over requests, restarts and OOMKills) and rank them, highest first")
+	f.StringVar(&opts.sortBy, "sort-by", "", "With --usage, sort the pods by: cpu (the default) or memory")
+	f.StringVar(&opts.topology, "topology", topology.LevelNode, "List pods on all nodes sharing the pod's node topology. One of: node, zone, region, or a node label key (e.g. example.com/rack)")
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	targets := 0
+	for _, target := range []string{opts.podName + opts.workloadName, opts.selector, opts.nodeName, opts.ip, opts.containerID} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets == 0 {
+		return ErrPodNameRequired{}
+	} else if targets > 1 {
+		return errs.ErrUsage{Err: fmt.Errorf("only one of a pod name, a selector, a node, an IP and a container ID can be given")}
+	}
+
+	_, err = topology.Keys(opts.topology)
+	if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	var printer output.Printer
+	if opts.watch {
+		printer, err = output.NewStreamPrinter(opts.output)
+	} else {
+		printer, err = output.NewPrinter(opts.output)
+	}
+	if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	contextNames, err := opts.connection.ContextNames()
+	if err != nil {
+		return err
+	}
+	if opts.watch && contextNames != nil {
+		return errs.ErrUsage{Err: fmt.Errorf("--watch cannot be used with --contexts or --all-contexts")}
+	}
+	if opts.resources && (opts.watch || contextNames != nil) {
+		return errs.ErrUsage{Err: fmt.Errorf("--resources cannot be used with --watch, --contexts or --all-contexts")}
+	}
+	// The NODE TOTAL and ALLOCATABLE rows of --resources have no object to
+	// print in other formats.
+	if _, ok := printer.(*output.TablePrinter); opts.resources && !ok {
+		return errs.ErrUsage{Err: fmt.Errorf("--resources can only be used with --output wide")}
+	}
+	if opts.usage && (opts.watch || opts.resources || contextNames != nil) {
+		return errs.ErrUsage{Err: fmt.Errorf("--usage cannot be used with --watch, --resources, --contexts or --all-contexts")}
+	}
+	if opts.risk && (opts.watch || opts.resources || opts.usage || contextNames != nil) {
+		return errs.ErrUsage{Err: fmt.Errorf("--risk cannot be used with --watch, --resources, --usage, --contexts or --all-contexts")}
+	}
+	if opts.sortBy != "" && !opts.usage {
+		return errs.ErrUsage{Err: fmt.Errorf("--sort-by requires --usage")}
+	} else if opts.sortBy != "" && opts.sortBy != "cpu" && opts.sortBy != "memory" {
+		return errs.ErrUsage{Err: fmt.Errorf("invalid --sort-by: %q (must be cpu or memory)", opts.sortBy)}
+	}
+
+	var results []contextPods
+	var contextsErr error
+	if contextNames == nil {
+		opts.namespace, err = opts.connection.CurrentNamespace()
+		if err != nil {
+			return err
+		}
+
+		if p.Client == nil {
+			p.Client, err = opts.connection.NewClient()
+			if err != nil {
+				return err
+			}
+		}
+
+		if opts.watch {
+			return p.watch(ctx, opts, printer, writer)
+		}
+
+		pods, warnings, err := p.fetchPods(ctx, p.Client, opts)
+		if err != nil {
+			return fmt.Errorf("could not get pods: %w", err)
+		}
+		printWarnings(p.errOut(), "", warnings)
+		if opts.resources {
+			return printResources(ctx, p.Client, pods, printer, writer)
+		}
+		if opts.usage {
+			if p.Metrics == nil {
+				p.Metrics, err = opts.connection.NewMetricsClient()
+				if err != nil {
+					return err
+				}
+			}
+			err = addUsage(ctx, p.Metrics, pods, opts.sortBy, p.errOut())
+			if err != nil {
+				return err
+			}
+		}
+		if opts.risk {
+			addRisk(pods, time.Now())
+		}
+		results = []contextPods{{pods: pods}}
+	} else {
+		results, contextsErr = p.fetchContextsPods(ctx, opts, contextNames)
+		if len(results) == 0 {
+			return contextsErr
+		}
+	}
+
+	allPods := []podInfo{}
+	for _, result := range results {
+		allPods = append(allPods, result.pods...)
+	}
+	// The node is always the same unless pods on several nodes are listed.
+	nodeColumnIsWide := opts.topology == topology.LevelNode && singleTarget(opts) && !spansNodes(allPods)
+	table := output.Table{}
+	for _, result := range results {
+		for _, pod := range result.pods {
+			// With several contexts, show which one each pod is in.
+			if contextNames != nil {
+				table.Rows = append(table.Rows, podRow(opts, pod, result.context))
+			} else {
+				table.Rows = append(table.Rows, podRow(opts, pod))
+			}
+		}
+	}
+	if contextNames != nil {
+		table.Columns = podColumns(opts, nodeColumnIsWide, "CLUSTER")
+	} else {
+		table.Columns = podColumns(opts, nodeColumnIsWide)
+	}
+	err = printer.Print(table, writer)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return contextsErr
+}
+
+// watch prints the nearby pods with an EVENT column and then each change
+// until the context is done.
+func (p *PodsCLI) watch(ctx context.Context, opts options, printer output.Printer, writer io.Writer) error {
+	result, err := fetchNearby(ctx, p.Client, opts)
+	if err != nil {
+		return fmt.Errorf("could not get pods: %w", err)
+	}
+	printWarnings(p.errOut(), "", unscheduledWarnings(result))
+
+	nodeColumnIsWide := opts.topology == topology.LevelNode && singleTarget(opts) && len(result.Nodes) == 1
+	columns := podColumns(opts, nodeColumnIsWide, "EVENT")
+	err = nearby.WatchPods(ctx, p.Client, result, nearbyOptions(opts), func(events []nearby.PodEvent) error {
+		table := output.Table{Columns: columns}
+		for _, event := range events {
+			table.Rows = append(table.Rows, podRow(opts, newPodInfo(event.Neighbor), event.Type))
+		}
+		err := printer.Print(table, writer)
+		if err != nil {
+			return fmt.Errorf("printing output: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not watch pods: %w", err)
+	}
+	return nil
+}
+
+// printResources prints the requests and limits of each pod, followed by the
+// total of the pods on its node and the node's allocatable resources.
+func printResources(ctx context.Context, client kubernetes.Interface, pods []podInfo, printer output.Printer, writer io.Writer) error {
+	table := output.Table{Columns: []output.Column{{Name: "NAMESPACE"}, {Name: "NAME"}, {Name: "NODE"}}}
+	for _, name := range nearby.ResourceNames {
+		label := strings.ToUpper(string(name))
+		table.Columns = append(table.Columns, output.Column{Name: label + " REQUESTS"}, output.Column{Name: label + " LIMITS"})
+	}
+	// Finished pods don't hold their resources anymore, so they aren't in the
+	// node's total either.
+	active := []podInfo{}
+	for _, pod := range pods {
+		if pod.pod.Status.Phase != v1.PodSucceeded && pod.pod.Status.Phase != v1.PodFailed {
+			active = append(active, pod)
+		}
+	}
+	pods = active
+	allocations := map[string]*nearby.NodeAllocationResult{}
+	for i, pod := range pods {
+		allocation, ok := allocations[pod.nodeName]
+		if !ok {
+			var err error
+			allocation, err = nearby.NodeAllocation(ctx, client, pod.nodeName)
+			if err != nil {
+				return fmt.Errorf("could not get node resources: %w", err)
+			}
+			allocations[pod.nodeName] = allocation
+		}
+		resources := nearby.ResourcesOf(pod.pod)
+		table.Rows = append(table.Rows, output.Row{
+			Cells:  resourcesRow(pod.namespace, pod.name, allocation, resources.Requests, resources.Limits),
+			Object: pod.pod,
+		})
+
+		// Pods are sorted by node, so the node's pods end here.
+		if i == len(pods)-1 || pods[i+1].nodeName != pod.nodeName {
+			table.Rows = append(table.Rows, output.Row{Cells: resourcesRow("", fmt.Sprintf("NODE TOTAL (%v pods, all namespaces)", allocation.Pods), allocation, allocation.Requests, allocation.Limits)})
+			allocatable := []string{"", "ALLOCATABLE", allocation.Node}
+			for _, name := range nearby.ResourceNames {
+				quantity := allocation.Allocatable[name]
+				allocatable = append(allocatable, quantity.String(), "-")
+			}
+			table.Rows = append(table.Rows, output.Row{Cells: allocatable})
+		}
+	}
+	err := printer.Print(table, writer)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return nil
+}
+
+// resourcesRow returns a row with each request and limit and its share of
+// the node's allocatable resources, e.g. 100m (5%).
+func resourcesRow(namespace string, name string, allocation *nearby.NodeAllocationResult, requests v1.ResourceList, limits v1.ResourceList) []string {
+	row := []string{namespace, name, allocation.Node}
+	for _, resourceName := range nearby.ResourceNames {
+		for _, list := range []v1.ResourceList{requests, limits} {
+			quantity := list[resourceName]
+			row = append(row, fmt.Sprintf("%v (%v%%)", quantity.String(), allocation.Share(resourceName, quantity)))
+		}
+	}
+	return row
+}
+
+// podColumns returns the table columns, starting with the given extra
+// columns (e.g. CLUSTER).
+func podColumns(opts options, nodeColumnIsWide bool, extra ...string) []output.Column {
+	columns := []output.Column{}
+	for _, name := range extra {
+		columns = append(columns, output.Column{Name: name})
+	}
+	columns = append(columns,
+		output.Column{Name: "NAMESPACE"},
+		output.Column{Name: "NAME"},
+		output.Column{Name: "READY"},
+		output.Column{Name: "STATUS"},
+		output.Column{Name: "RESTARTS"},
+		output.Column{Name: "AGE"},
+	)
+	if opts.usage {
+		columns = append(columns, output.Column{Name: "CPU"}, output.Column{Name: "MEMORY"})
+	}
+	if opts.risk {
+		columns = append(columns, output.Column{Name: "RISK"}, output.Column{Name: "REASONS"})
+	}
+	columns = append(columns,
+		output.Column{Name: "IP", Wide: true},
+		output.Column{Name: "NODE", Wide: nodeColumnIsWide},
+		output.Column{Name: "NOMINATED NODE", Wide: true},
+	)
+	// With several targets, show which of them each pod is near.
+	if !singleTarget(opts) {
+		columns = append(columns, output.Column{Name: "NEAR"})
+	}
+	return columns
+}
+
+// podRow returns the table row for the pod, starting with the cells of the
+// extra columns.
+func podRow(opts options, pod podInfo, extra ...string) output.Row {
+	containersReady := fmt.Sprintf("%v/%v", pod.containersReadyCount, pod.containersCount)
+	row := output.Row{Object: pod.pod}
+	row.Cells = append(row.Cells, extra...)
+	row.Cells = append(row.Cells,
+		pod.namespace, pod.name, containersReady, pod.status, strconv.FormatInt(int64(pod.restartCount), 10), pod.age,
+	)
+	if opts.usage && pod.usage != nil {
+		row.Cells = append(row.Cells, output.CPU(pod.usage.CPU), output.Memory(pod.usage.Memory))
+	} else if opts.usage {
+		row.Cells = append(row.Cells, "<unknown>", "<unknown>")
+	}
+	if opts.risk && pod.risk != nil {
+		row.Cells = append(row.Cells, strconv.Itoa(pod.risk.Score), output.NoneIfEmpty(strings.Join(pod.risk.Reasons, ", ")))
+	}
+	row.Cells = append(row.Cells,
+		output.NoneIfEmpty(pod.ip), output.NoneIfEmpty(pod.nodeName), output.NoneIfEmpty(pod.nominatedNodeName),
+	)
+	if !singleTarget(opts) {
+		row.Cells = append(row.Cells, output.NoneIfEmpty(strings.Join(pod.near, ",")))
+	}
+	return row
+}
+
+// errOut returns the writer for warnings.
+func (p *PodsCLI) errOut() io.Writer {
+	if p.ErrOut == nil {
+		return os.Stderr
+	}
+	return p.ErrOut
+}
+
+// addUsage adds the usage of each pod from the metrics API and sorts the
+// pods by CPU or memory (the sortBy), highest first. Pods without metrics are
+// sorted last. If the metrics API is unavailable, a warning is printed to
+// errOut and the pods are left as they are.
+func addUsage(ctx context.Context, client metricsclientset.Interface, pods []podInfo, sortBy string, errOut io.Writer) error {
+	podList := []v1.Pod{}
+	for _, pod := range pods {
+		podList = append(podList, *pod.pod)
+	}
+	usage, err := nearby.PodUsage(ctx, client, podList)
+	var unavailable errs.ErrMetricsUnavailable
+	if errors.As(err, &unavailable) {
+		fmt.Fprintf(errOut, "WARNING: %v\n", err)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not get pod usage: %w", err)
+	}
+
+	for i, pod := range pods {
+		if podUsage, ok := usage[types.NamespacedName{Namespace: pod.namespace, Name: pod.name}]; ok {
+			pods[i].usage = &podUsage
+		}
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		a, b := pods[i].usage, pods[j].usage
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		if sortBy == "memory" {
+			return a.Memory.Cmp(b.Memory) > 0
+		}
+		return a.CPU.Cmp(b.CPU) > 0
+	})
+	return nil
+}
+
+// addRisk scores each pod with nearby.RiskOf and ranks the pods by their
+// score, highest first.
+func addRisk(pods []podInfo, now time.Time) {
+	for i, pod := range pods {
+		risk := nearby.RiskOf(pod.pod, now)
+		pods[i].risk = &risk
+	}
+	sort.SliceStable(pods, func(i, j int) bool {
+		return pods[i].risk.Score > pods[j].risk.Score
+	})
+}
+
+// fetchContextsPods fetches the nearby pods in each of the contexts
+// concurrently. It returns the results of the contexts that succeeded, in
+// order, and an errs.ErrContexts if any failed. Their warnings are printed
+// once every context is done, prefixed with the context name.
+func (p *PodsCLI) fetchContextsPods(ctx context.Context, opts options, contextNames []string) ([]contextPods, error) {
+	results := make([]contextPods, len(contextNames))
+	failures := make([]error, len(contextNames))
+	var wg sync.WaitGroup
+	for i, contextName := range contextNames {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].context = contextName
+			results[i].pods, results[i].warnings, failures[i] = p.fetchContextPods(ctx, opts, contextName)
+		}()
+	}
+	wg.Wait()
+	for _, result := range results {
+		printWarnings(p.errOut(), result.context, result.warnings)
+	}
+
+	succeeded := []contextPods{}
+	contextsErr := errs.ErrContexts{Errs: map[string]error{}, Total: len(contextNames)}
+	for i, result := range results {
+		if failures[i] != nil {
+			contextsErr.Errs[result.context] = failures[i]
+			continue
+		}
+		succeeded = append(succeeded, result)
+	}
+	if len(contextsErr.Errs) > 0 {
+		return succeeded, contextsErr
+	}
+	return succeeded, nil
+}
+
+// fetchContextPods fetches the nearby pods in the given context, using the
+// context's namespace unless --namespace is given.
+func (p *PodsCLI) fetchContextPods(ctx context.Context, opts options, contextName string) ([]podInfo, []string, error) {
+	connection := opts.connection.ForContext(contextName)
+	var err error
+	opts.namespace, err = connection.CurrentNamespace()
+	if err != nil {
+		return nil, nil, err
+	}
+	client := p.Clients[contextName]
+	if client == nil {
+		client, err = connection.NewClient()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return p.fetchPods(ctx, client, opts)
+}
+
+// fetchPods returns the nearby pods and the warnings about unscheduled
+// targets.
+func (p *PodsCLI) fetchPods(ctx context.Context, client kubernetes.Interface, opts options) ([]podInfo, []string, error) {
+	result, err := fetchNearby(ctx, client, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pods []podInfo
+	for _, neighbor := range result.Neighbors {
+		pods = append(pods, newPodInfo(neighbor))
+	}
+	return pods, unscheduledWarnings(result), nil
+}
+
+// fetchNearby finds the pods near the target.
+func fetchNearby(ctx context.Context, client kubernetes.Interface, opts options) (*nearby.PodsResult, error) {
+	var result *nearby.PodsResult
+	var err error
+	switch {
+	case opts.workloadKind != "":
+		result, err = nearby.PodsNearWorkload(ctx, client, opts.namespace, opts.workloadKind, opts.workloadName, nearbyOptions(opts))
+	case opts.selector != "":
+		result, err = nearby.PodsNearSelector(ctx, client, opts.namespace, opts.selector, nearbyOptions(opts))
+	case opts.nodeName != "":
+		result, err = nearby.PodsNearNode(ctx, client, opts.namespace, opts.nodeName, nearbyOptions(opts))
+	case opts.ip != "":
+		result, err = podsNearLookup(ctx, client, opts, nearby.PodByIP, opts.ip)
+	case opts.containerID != "":
+		result, err = podsNearLookup(ctx, client, opts, nearby.PodByContainerID, opts.containerID)
+	default:
+		result, err = nearby.PodsNearPod(ctx, client, opts.namespace, opts.podName, nearbyOptions(opts))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// podsNearLookup finds the pods near the pod returned by the lookup (e.g.
+// nearby.PodByIP).
+func podsNearLookup(ctx context.Context, client kubernetes.Interface, opts options, lookup func(context.Context, kubernetes.Interface, string) (*v1.Pod, error), value string) (*nearby.PodsResult, error) {
+	pod, err := lookup(ctx, client, value)
+	if err != nil {
+		return nil, err
+	}
+	return nearby.PodsNearPods(ctx, client, []v1.Pod{*pod}, nearbyOptions(opts))
+}
+
+func nearbyOptions(opts options) nearby.PodOptions {
+	return nearby.PodOptions{
+		Topology:      opts.topology,
+		AllNamespaces: opts.allNamespaces,
+	}
+}
+
+func newPodInfo(neighbor nearby.Neighbor) podInfo {
+	pod := neighbor.Pod
+	containersReadyCount := 0
+	var restartCount int32 = 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			containersReadyCount += 1
+		}
+		restartCount += status.RestartCount
+	}
+
+	age := output.Age(time.Since(pod.CreationTimestamp.Time))
+
+	status := podStatusOutput(pod.Status)
+
+	return podInfo{
+		age:                  age,
+		containersCount:      len(pod.Status.ContainerStatuses),
+		containersReadyCount: containersReadyCount,
+		ip:                   pod.Status.PodIP,
+		name:                 pod.Name,
+		namespace:            pod.Namespace,
+		near:                 neighbor.Near,
+		nodeName:             pod.Spec.NodeName,
+		nominatedNodeName:    pod.Status.NominatedNodeName,
+		pod:                  pod.DeepCopy(),
+		restartCount:         restartCount,
+		status:               status,
+	}
+}
+
+// unscheduledWarnings tells the user which targets are unscheduled and which
+// nodes, if any, are listed in their place.
+func unscheduledWarnings(result *nearby.PodsResult) []string {
+	warnings := []string{}
+	for _, target := range result.Targets {
+		if !target.Unscheduled {
+			continue
+		}
+		if len(target.Nodes) == 0 {
+			err := errs.ErrUnscheduled{
+				Namespace:         target.Pod.Namespace,
+				Name:              target.Pod.Name,
+				NominatedNodeName: target.Pod.Status.NominatedNodeName,
+			}
+			warnings = append(warnings, fmt.Sprintf("WARNING: %v", err))
+			continue
+		}
+		nominated := ""
+		if target.Pod.Status.NominatedNodeName != "" {
+			nominated = fmt.Sprintf(" (nominated node: %v)", target.Pod.Status.NominatedNodeName)
+		}
+		warnings = append(warnings, fmt.Sprintf("Pod %v/%v is unscheduled%v. Listing pods on the nodes it could be scheduled on: %v", target.Pod.Namespace, target.Pod.Name, nominated, strings.Join(target.Nodes, ", ")))
+	}
+	return warnings
+}
+
+// printWarnings writes each warning on its own line, prefixed with the
+// context name if one is given.
+func printWarnings(errOut io.Writer, contextName string, warnings []string) {
+	for _, warning := range warnings {
+		if contextName != "" {
+			warning = fmt.Sprintf("[%v] %v", contextName, warning)
+		}
+		fmt.Fprintln(errOut, warning)
+	}
+}
+
+// singleTarget returns true if the pods are listed near a single pod or node,
+// so there's no need to show which target each pod is near.
+func singleTarget(opts options) bool {
+	return opts.podName != "" || opts.nodeName != "" || opts.ip != "" || opts.containerID != ""
+}
+
+// spansNodes returns true if the pods are on more than one node (e.g. the
+// candidate nodes of an unscheduled pod).
+func spansNodes(pods []podInfo) bool {
+	for _, pod := range pods {
+		if pod.nodeName != pods[0].nodeName {
+			return true
+		}
+	}
+	return false
+}
+
+func podStatusOutput(podStatus v1.PodStatus) string {
+	output := string(podStatus.Phase)
+	if output != "Pending" {
+		for _, status := range podStatus.ContainerStatuses {
+			if status.State.Waiting != nil {
+				return status.State.Waiting.Reason
+			} else if status.State.Running != nil {
+				output = "Running"
+			} else if status.State.Terminated != nil {
+				return status.State.Terminated.Reason
+			}
+		}
+	}
+	return output
+}
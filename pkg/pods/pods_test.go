@@ -0,0 +1,727 @@
+package pods_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/pods"
+)
+
+func TestExecute(t *testing.T) {
+	setupTestKubeconfig(t)
+
+	t.Run("with --help", func(t *testing.T) {
+		for _, args := range [][]string{{"-h"}, {"--help"}} {
+			expected := "USAGE"
+			writer := bytes.NewBufferString("")
+			podsCLI := pods.PodsCLI{
+				Client: testclient.NewSimpleClientset(),
+			}
+			err := podsCLI.Execute(args, writer)
+			if err != nil {
+				t.Errorf("Unexpected error: %v\n", err)
+			}
+			output, _ := ioutil.ReadAll(writer)
+			if !strings.Contains(string(output), expected) {
+				t.Errorf("Expected help output for %v to include: %v, got: \n%v", args, expected, string(output))
+			}
+		}
+	})
+
+	t.Run("with no pod name, it returns an error", func(t *testing.T) {
+		for _, args := range [][]string{{}, {"--all-namespaces"}} {
+			podsCLI := pods.PodsCLI{
+				Client: testclient.NewSimpleClientset(),
+			}
+			err := podsCLI.Execute(args, bytes.NewBufferString(""))
+			got, ok := err.(pods.ErrPodNameRequired)
+			if !ok {
+				t.Errorf("Expected error type for %v: %T, got: %T\n", args, got, err)
+			}
+			if errs.ExitCode(err) != errs.ExitUsage {
+				t.Errorf("Expected exit code: %v, got: %v\n", errs.ExitUsage, errs.ExitCode(err))
+			}
+		}
+	})
+
+	t.Run("with an invalid flag, it returns an error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testclient.NewSimpleClientset(),
+		}
+		err := podsCLI.Execute([]string{"--not-a-valid-flag"}, bytes.NewBufferString(""))
+		want := "flag provided but not defined: -not-a-valid-flag"
+		if err == nil || want != err.Error() {
+			t.Errorf("Expected error: %v, got: %v", want, err)
+		}
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected exit code: %v, got: %v\n", errs.ExitUsage, errs.ExitCode(err))
+		}
+	})
+
+	t.Run("with a pod name and a selector, it returns an error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testclient.NewSimpleClientset(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "-l", "app=web"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+
+	t.Run("with a pod name and a node, it returns an error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testclient.NewSimpleClientset(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--node", "node-a-1"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+
+	t.Run("with an unknown node, it returns a not found error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"--node", "missing"}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+
+	t.Run("with an unknown IP, it returns a not found error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"--ip", "10.9.9.9"}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+
+	t.Run("with an unknown pod, it returns a not found error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"missing"}, bytes.NewBufferString(""))
+		var notFound errs.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", notFound, err, err)
+		}
+	})
+
+	t.Run("with a pod name, returns pods on the same node in the kubeconfig's namespace", func(t *testing.T) {
+		expected := `NAMESPACE                NAME          READY  STATUS   RESTARTS  AGE
+testing-cluster-default  nginx-abc123  1/1    Running  0         60m
+testing-cluster-default  redis-0       1/1    Running  2         60m
+`
+		got := execute(t, []string{"nginx-abc123"})
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"with --all-namespaces, includes pods from other namespaces",
+			[]string{"nginx-abc123", "--all-namespaces", "-o", "name"},
+			"pod/kube-system/fluentd-a1\npod/testing-cluster-default/nginx-abc123\npod/testing-cluster-default/redis-0\n",
+		},
+		{
+			"with --namespace, finds the pod in the given namespace",
+			[]string{"api-1", "--namespace", "my-namespace", "-o", "name"},
+			"pod/my-namespace/api-1\n",
+		},
+		{
+			"with --kubeconfig, uses the kubeconfig's namespace",
+			[]string{"worker-1", "--kubeconfig", path.Join(testdataDirectory(t), "test-kube-config"), "-o", "name"},
+			"pod/testing-namespace/worker-1\n",
+		},
+		{
+			"with --context, uses the context's namespace",
+			[]string{"batch-1", "--kubeconfig", path.Join(testdataDirectory(t), "test-kube-config"), "--context", "other-context", "-o", "name"},
+			"pod/other-namespace/batch-1\n",
+		},
+		{
+			"with --topology zone, includes pods on nodes in the same zone",
+			[]string{"nginx-abc123", "--topology", "zone", "-o", "custom-columns=NAME:.metadata.name,NODE:.spec.nodeName"},
+			"NAME          NODE\nnginx-abc123  node-a-1\nredis-0       node-a-1\nweb-1         node-a-2\n",
+		},
+		{
+			"with a selector, includes pods on the nodes of every matching pod",
+			[]string{"-l", "app=web", "-o", "name"},
+			"pod/testing-cluster-default/web-1\npod/testing-cluster-default/web-2\n",
+		},
+		{
+			"with a workload, includes pods on the nodes of the workload's pods",
+			[]string{"sts/redis", "-o", "name"},
+			"pod/testing-cluster-default/nginx-abc123\npod/testing-cluster-default/redis-0\n",
+		},
+		{
+			"with --node, includes pods on the node",
+			[]string{"--node", "node-a-1", "-o", "name"},
+			"pod/testing-cluster-default/nginx-abc123\npod/testing-cluster-default/redis-0\n",
+		},
+		{
+			"with --node and --topology zone, includes pods on nodes in the node's zone",
+			[]string{"--node", "node-a-2", "--topology", "zone", "-o", "name"},
+			"pod/testing-cluster-default/nginx-abc123\npod/testing-cluster-default/redis-0\npod/testing-cluster-default/web-1\n",
+		},
+		{
+			"with --ip, includes pods near the pod with the IP in its namespace",
+			[]string{"--ip", "10.2.3.4", "-o", "name"},
+			"pod/my-namespace/api-1\n",
+		},
+		{
+			"with --container-id, includes pods near the pod with the container",
+			[]string{"--container-id", "containerd://0123", "--all-namespaces", "-o", "name"},
+			"pod/my-namespace/api-1\npod/other-namespace/batch-1\npod/testing-cluster-default/web-2\npod/testing-namespace/worker-1\n",
+		},
+		{
+			"with an unscheduled pod, includes pods on the nodes it could be scheduled on",
+			[]string{"pending-1", "-o", "name"},
+			"pod/testing-cluster-default/web-1\n",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := execute(t, testCase.args)
+			if testCase.expected != got {
+				t.Errorf("Expected output:\n%v\ngot:\n%v", testCase.expected, got)
+			}
+		})
+	}
+
+	t.Run("with several targets, shows the node and the targets each pod is near", func(t *testing.T) {
+		expected := `NAMESPACE                NAME   READY  STATUS   RESTARTS  AGE  NODE      NEAR
+testing-cluster-default  web-1  1/1    Running  0         60m  node-a-2  web-1
+testing-cluster-default  web-2  1/1    Running  0         60m  node-b-1  web-2
+`
+		got := execute(t, []string{"-l", "app=web"})
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an unscheduled pod, reports the nodes listed in its place", func(t *testing.T) {
+		expectedWarning := "Pod testing-cluster-default/pending-1 is unscheduled. Listing pods on the nodes it could be scheduled on: node-a-2\n"
+		errOut := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+			ErrOut: errOut,
+		}
+		err := podsCLI.Execute([]string{"pending-1", "-o", "name"}, bytes.NewBufferString(""))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expectedWarning != errOut.String() {
+			t.Errorf("Expected warning:\n%v\ngot:\n%v", expectedWarning, errOut.String())
+		}
+	})
+
+	t.Run("with an unschedulable pod, returns an unscheduled error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"pending-2"}, bytes.NewBufferString(""))
+		var unscheduled errs.ErrUnscheduled
+		if !errors.As(err, &unscheduled) {
+			t.Errorf("Expected error type: %T, got: %T (%v)", unscheduled, err, err)
+		}
+	})
+}
+
+func TestExecuteContexts(t *testing.T) {
+	setupTestKubeconfig(t)
+	kubeconfig := path.Join(testdataDirectory(t), "test-kube-config")
+
+	t.Run("with --all-contexts, lists pods from every context with a CLUSTER column", func(t *testing.T) {
+		expected := `CLUSTER        NAMESPACE          NAME      READY  STATUS   RESTARTS  AGE  NODE
+other-context  other-namespace    worker-1  1/1    Running  0         60m  node-x-1
+test-context   testing-namespace  worker-1  1/1    Running  0         60m  node-b-1
+`
+		writer := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Clients: map[string]kubernetes.Interface{
+				"test-context":  testClient(),
+				"other-context": testclient.NewSimpleClientset(testPod("other-namespace", "worker-1", "node-x-1", nil)),
+			},
+		}
+		err := podsCLI.Execute([]string{"worker-1", "--kubeconfig", kubeconfig, "--all-contexts"}, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+	})
+
+	t.Run("with an unscheduled pod in a context, prefixes its warning with the context name", func(t *testing.T) {
+		pending := testPod("other-namespace", "worker-1", "", nil)
+		pending.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+		pending.Status = v1.PodStatus{Phase: v1.PodPending}
+		expected := "pod/other-namespace/batch-1\npod/testing-namespace/worker-1\n"
+		expectedWarning := "[other-context] Pod other-namespace/worker-1 is unscheduled. Listing pods on the nodes it could be scheduled on: node-x-1\n"
+		writer := bytes.NewBufferString("")
+		errOut := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Clients: map[string]kubernetes.Interface{
+				"test-context": testClient(),
+				"other-context": testclient.NewSimpleClientset(
+					testNode("node-x-1", map[string]string{"disk": "ssd"}),
+					pending,
+					testPod("other-namespace", "batch-1", "node-x-1", nil),
+				),
+			},
+			ErrOut: errOut,
+		}
+		err := podsCLI.Execute([]string{"worker-1", "--kubeconfig", kubeconfig, "--all-contexts", "-o", "name"}, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+		if expectedWarning != errOut.String() {
+			t.Errorf("Expected warning:\n%v\ngot:\n%v", expectedWarning, errOut.String())
+		}
+	})
+
+	t.Run("with a failing context, lists the others and returns its error", func(t *testing.T) {
+		writer := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Clients: map[string]kubernetes.Interface{
+				"test-context":  testClient(),
+				"other-context": testclient.NewSimpleClientset(),
+			},
+		}
+		err := podsCLI.Execute([]string{"worker-1", "--kubeconfig", kubeconfig, "--contexts", "test-context,other-context", "-o", "name"}, writer)
+		var contextsErr errs.ErrContexts
+		if !errors.As(err, &contextsErr) {
+			t.Fatalf("Expected error type: %T, got: %T (%v)", contextsErr, err, err)
+		}
+		if _, ok := contextsErr.Errs["other-context"]; !ok || len(contextsErr.Errs) != 1 {
+			t.Errorf("Expected other-context to fail, got: %v", err)
+		}
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected exit code: %v, got: %v", errs.ExitNotFound, errs.ExitCode(err))
+		}
+		expected := "pod/testing-namespace/worker-1\n"
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+	})
+
+	t.Run("with --context and --contexts, returns a usage error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{}
+		err := podsCLI.Execute([]string{"worker-1", "--kubeconfig", kubeconfig, "--context", "test-context", "--contexts", "other-context"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func TestExecuteWatch(t *testing.T) {
+	setupTestKubeconfig(t)
+
+	expected := `EVENT  NAMESPACE                NAME          READY  STATUS   RESTARTS  AGE
+ADDED  testing-cluster-default  nginx-abc123  1/1    Running  0         60m
+ADDED  testing-cluster-default  redis-0       1/1    Running  2         60m
+`
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		done <- podsCLI.ExecuteContext(ctx, []string{"nginx-abc123", "--watch"}, writer)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for writer.String() != expected && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	err := <-done
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if expected != writer.String() {
+		t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+	}
+
+	t.Run("with --output json, returns a usage error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--watch", "-o", "json"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func TestExecuteResources(t *testing.T) {
+	setupTestKubeconfig(t)
+
+	node := testNode("node-a-1", nil)
+	node.Status.Allocatable = v1.ResourceList{
+		v1.ResourceCPU:              resource.MustParse("2"),
+		v1.ResourceMemory:           resource.MustParse("4Gi"),
+		v1.ResourceEphemeralStorage: resource.MustParse("100Gi"),
+	}
+	withResources := func(pod *v1.Pod, requests v1.ResourceList, limits v1.ResourceList) *v1.Pod {
+		pod.Spec.Containers = []v1.Container{{Name: "main", Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}}
+		return pod
+	}
+	// Finished pods are neither listed nor in the node's total.
+	finished := withResources(testPod("testing-cluster-default", "migrate-1", "node-a-1", nil),
+		v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		nil,
+	)
+	finished.Status.Phase = v1.PodSucceeded
+	client := testclient.NewSimpleClientset(
+		node,
+		withResources(testPod("testing-cluster-default", "nginx-abc123", "node-a-1", nil),
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("1Gi")},
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("2Gi")},
+		),
+		withResources(testPod("kube-system", "fluentd-a1", "node-a-1", nil),
+			v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceEphemeralStorage: resource.MustParse("10Gi")},
+			nil,
+		),
+		finished,
+	)
+
+	// The total includes fluentd-a1 in kube-system, which isn't listed.
+	expected := `NAMESPACE                NAME                                 NODE      CPU REQUESTS  CPU LIMITS  MEMORY REQUESTS  MEMORY LIMITS  EPHEMERAL-STORAGE REQUESTS  EPHEMERAL-STORAGE LIMITS
+testing-cluster-default  nginx-abc123                         node-a-1  500m (25%)    1 (50%)     1Gi (25%)        2Gi (50%)      0 (0%)                      0 (0%)
+                         NODE TOTAL (2 pods, all namespaces)  node-a-1  600m (30%)    1 (50%)     1Gi (25%)        2Gi (50%)      10Gi (10%)                  0 (0%)
+                         ALLOCATABLE                          node-a-1  2             -           4Gi              -              100Gi                       -
+`
+	for _, args := range [][]string{{"--resources"}, {"--resources", "-o", "wide"}} {
+		t.Run(fmt.Sprintf("with %v, shows each pod's share and the node total", strings.Join(args, " ")), func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			podsCLI := pods.PodsCLI{
+				Client: client,
+			}
+			err := podsCLI.Execute(append([]string{"nginx-abc123"}, args...), writer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if expected != writer.String() {
+				t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+			}
+		})
+	}
+
+	for _, format := range []string{"json", "yaml", "name", "custom-columns=NAME:.metadata.name", "jsonpath={.items[*].metadata.name}"} {
+		t.Run(fmt.Sprintf("with --resources and -o %v, returns a usage error", format), func(t *testing.T) {
+			podsCLI := pods.PodsCLI{
+				Client: client,
+			}
+			err := podsCLI.Execute([]string{"nginx-abc123", "--resources", "-o", format}, bytes.NewBufferString(""))
+			if errs.ExitCode(err) != errs.ExitUsage {
+				t.Errorf("Expected a usage error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestExecuteUsage(t *testing.T) {
+	setupTestKubeconfig(t)
+
+	metrics := metricsClient(t,
+		podMetrics("testing-cluster-default", "nginx-abc123", "15m", "200Mi"),
+		podMetrics("testing-cluster-default", "redis-0", "250m", "64Mi"),
+	)
+	var testCases = []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			"with --usage, shows the usage of each pod sorted by CPU",
+			[]string{"nginx-abc123", "--usage"},
+			`NAMESPACE                NAME          READY  STATUS   RESTARTS  AGE  CPU   MEMORY
+testing-cluster-default  redis-0       1/1    Running  2         60m  250m  64Mi
+testing-cluster-default  nginx-abc123  1/1    Running  0         60m  15m   200Mi
+`,
+		},
+		{
+			"with --sort-by memory, sorts the pods by memory",
+			[]string{"nginx-abc123", "--usage", "--sort-by", "memory", "-o", "name"},
+			"pod/testing-cluster-default/nginx-abc123\npod/testing-cluster-default/redis-0\n",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			writer := bytes.NewBufferString("")
+			podsCLI := pods.PodsCLI{
+				Client:  testClient(),
+				Metrics: metrics,
+			}
+			err := podsCLI.Execute(testCase.args, writer)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if testCase.expected != writer.String() {
+				t.Errorf("Expected output:\n%v\ngot:\n%v", testCase.expected, writer.String())
+			}
+		})
+	}
+
+	t.Run("without metrics-server, shows unknown usage", func(t *testing.T) {
+		unavailable := metricsfake.NewSimpleClientset()
+		unavailable.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "metrics.k8s.io", Resource: "pods"}, "")
+		})
+		expected := `NAMESPACE                NAME          READY  STATUS   RESTARTS  AGE  CPU        MEMORY
+testing-cluster-default  nginx-abc123  1/1    Running  0         60m  <unknown>  <unknown>
+testing-cluster-default  redis-0       1/1    Running  2         60m  <unknown>  <unknown>
+`
+		expectedWarning := "WARNING: metrics API unavailable (is metrics-server installed?): pods.metrics.k8s.io \"\" not found\n"
+		writer := bytes.NewBufferString("")
+		errOut := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Client:  testClient(),
+			Metrics: unavailable,
+			ErrOut:  errOut,
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--usage"}, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+		if expectedWarning != errOut.String() {
+			t.Errorf("Expected warning:\n%v\ngot:\n%v", expectedWarning, errOut.String())
+		}
+	})
+
+	t.Run("with --sort-by without --usage, returns a usage error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--sort-by", "cpu"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func TestExecuteRisk(t *testing.T) {
+	setupTestKubeconfig(t)
+
+	t.Run("with --risk, ranks the pods by their risk score", func(t *testing.T) {
+		expected := `NAMESPACE                NAME          READY  STATUS   RESTARTS  AGE  RISK  REASONS
+testing-cluster-default  redis-0       1/1    Running  2         60m  4     BestEffort, restarts: 2
+testing-cluster-default  nginx-abc123  1/1    Running  0         60m  3     BestEffort
+`
+		writer := bytes.NewBufferString("")
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--risk"}, writer)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != writer.String() {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, writer.String())
+		}
+	})
+
+	t.Run("with --risk and --watch, returns a usage error", func(t *testing.T) {
+		podsCLI := pods.PodsCLI{
+			Client: testClient(),
+		}
+		err := podsCLI.Execute([]string{"nginx-abc123", "--risk", "--watch"}, bytes.NewBufferString(""))
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+// metricsClient returns a fake metrics client with the pod metrics, added to
+// the tracker under the resource the client reads from (pods) rather than the
+// one NewSimpleClientset would guess from their kind.
+func metricsClient(t *testing.T, podMetrics ...*metricsv1beta1.PodMetrics) *metricsfake.Clientset {
+	t.Helper()
+	client := metricsfake.NewSimpleClientset()
+	for _, metrics := range podMetrics {
+		err := client.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("pods"), metrics, metrics.Namespace)
+		if err != nil {
+			t.Fatalf("adding metrics: %v", err)
+		}
+	}
+	return client
+}
+
+func podMetrics(namespace string, name string, cpu string, memory string) *metricsv1beta1.PodMetrics {
+	return &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Containers: []metricsv1beta1.ContainerMetrics{{
+			Name:  "main",
+			Usage: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu), v1.ResourceMemory: resource.MustParse(memory)},
+		}},
+	}
+}
+
+func execute(t *testing.T, args []string) string {
+	t.Helper()
+	writer := bytes.NewBufferString("")
+	podsCLI := pods.PodsCLI{
+		Client: testClient(),
+	}
+	err := podsCLI.Execute(args, writer)
+	if err != nil {
+		t.Fatalf("Unexpected error for %v: %v", args, err)
+	}
+	return writer.String()
+}
+
+// testClient returns a fake client with three nodes in two zones:
+//
+//	node-a-1 (us-east4-a): nginx-abc123, redis-0, kube-system/fluentd-a1
+//	node-a-2 (us-east4-a, disk=ssd): web-1
+//	node-b-1 (us-east4-b): web-2, my-namespace/api-1, testing-namespace/worker-1,
+//	  other-namespace/batch-1
+//
+// and two unscheduled pods: pending-1 (fits node-a-2) and pending-2 (fits
+// no node).
+func testClient() kubernetes.Interface {
+	controller := true
+	redis := testPod("testing-cluster-default", "redis-0", "node-a-1", nil)
+	redis.Labels = map[string]string{"app": "redis"}
+	redis.OwnerReferences = []metav1.OwnerReference{{Kind: "StatefulSet", Name: "redis", UID: "redis-uid", Controller: &controller}}
+	redis.Status.ContainerStatuses[0].RestartCount = 2
+
+	api := testPod("my-namespace", "api-1", "node-b-1", nil)
+	api.Status.PodIP = "10.2.3.4"
+	api.Status.ContainerStatuses[0].ContainerID = "containerd://0123abcd"
+
+	pending := testPod("testing-cluster-default", "pending-1", "", nil)
+	pending.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	pending.Status = v1.PodStatus{Phase: v1.PodPending}
+	unschedulable := testPod("testing-cluster-default", "pending-2", "", nil)
+	unschedulable.Spec.NodeSelector = map[string]string{"disk": "nvme"}
+	unschedulable.Status = v1.PodStatus{Phase: v1.PodPending}
+
+	return testclient.NewSimpleClientset(
+		testNode("node-a-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-a"}),
+		testNode("node-a-2", map[string]string{"topology.kubernetes.io/zone": "us-east4-a", "disk": "ssd"}),
+		testNode("node-b-1", map[string]string{"topology.kubernetes.io/zone": "us-east4-b"}),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: "testing-cluster-default", UID: "redis-uid"},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			},
+		},
+		testPod("testing-cluster-default", "nginx-abc123", "node-a-1", map[string]string{"app": "nginx"}),
+		redis,
+		testPod("kube-system", "fluentd-a1", "node-a-1", nil),
+		testPod("testing-cluster-default", "web-1", "node-a-2", map[string]string{"app": "web"}),
+		testPod("testing-cluster-default", "web-2", "node-b-1", map[string]string{"app": "web"}),
+		api,
+		testPod("testing-namespace", "worker-1", "node-b-1", nil),
+		testPod("other-namespace", "batch-1", "node-b-1", nil),
+		pending,
+		unschedulable,
+	)
+}
+
+func testNode(name string, labels map[string]string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
+func testPod(namespace string, name string, nodeName string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(time.Hour * -1)),
+		},
+		Spec: v1.PodSpec{
+			NodeName: nodeName,
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "main",
+					Ready: true,
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				},
+			},
+		},
+	}
+}
+
+func testdataDirectory(t *testing.T) string {
+	t.Helper()
+	workingDirectory, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("working directory: %v", err)
+	}
+	return path.Join(workingDirectory, "../..", "testdata")
+}
+
+// setupTestKubeconfig configures a default kubeconfig path using the KUBECONFIG env variable.  This avoids unexpected test failures when a user has a namespace set in their kubeconfig file or they don't have a kubeconfig file at all (e.g. in CI).  Setting the KUBECONFIG env var is a close approximation to the user's default kubeconfig behavior and allows us to have predictable results.
+func setupTestKubeconfig(t *testing.T) {
+	t.Helper()
+	t.Setenv("KUBECONFIG", path.Join(testdataDirectory(t), "test-default-kube-config"))
+}
@@ -0,0 +1,183 @@
+// Package restarts provides a CLI to correlate the restarts of co-located
+// pods.
+package restarts
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+)
+
+// A RestartsCLI is used to create a command line interface for listing the
+// restarts of the pods co-located with a pod.
+type RestartsCLI struct {
+	Client kubernetes.Interface
+}
+
+// ErrPodNameRequired is returned when no pod name is given.
+type ErrPodNameRequired struct{}
+
+func (err ErrPodNameRequired) Error() string {
+	return "a pod name is required"
+}
+
+func (err ErrPodNameRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// Execute writes a timeline of the last terminations of the containers on the
+// pod's node to the given io.Writer and returns an error.
+func (r *RestartsCLI) Execute(args []string, writer io.Writer) error {
+	return r.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (r *RestartsCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	var podName string
+	remainingArgs := args
+	if len(args) > 0 {
+		matched, err := regexp.MatchString("^-", args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing arguments")
+		}
+		if !matched {
+			podName = args[0]
+			remainingArgs = args[1:]
+		}
+	}
+
+	f := flag.NewFlagSet("kubectl nearby restarts", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Show when the containers on a pod's node last terminated, to find restarts that happened together.\n\nUSAGE\n\n  %s restarts POD [OPTIONS]\n\nOPTIONS\n\n", os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+	allNamespaces := f.Bool("all-namespaces", false, "Include pods from all namespaces on the node")
+	window := f.Duration("window", 10*time.Minute, "Group terminations that finished within this long of each other into clusters")
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	if podName == "" {
+		return ErrPodNameRequired{}
+	}
+	if *window <= 0 {
+		return errs.ErrUsage{Err: fmt.Errorf("invalid --window: %v (must be positive)", *window)}
+	}
+
+	namespace, err := connection.CurrentNamespace()
+	if err != nil {
+		return err
+	}
+	if r.Client == nil {
+		r.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := nearby.RestartsNearPod(ctx, r.Client, namespace, podName, nearby.PodOptions{AllNamespaces: *allNamespaces})
+	if err != nil {
+		return err
+	}
+
+	header := fmt.Sprintf("Restarts near pod %v/%v on node %v", namespace, podName, result.Node)
+	if len(result.Terminations) == 0 {
+		_, err = fmt.Fprintf(writer, "%v: no containers have restarted\n", header)
+		if err != nil {
+			return fmt.Errorf("printing output: %v", err)
+		}
+		return nil
+	}
+
+	// Only clusters spanning several pods are numbered: a single pod
+	// restarting repeatedly says nothing about its neighbors.
+	clusters, oomKilled := 0, 0
+	rows := [][]string{{"LAST TERMINATED", "NAMESPACE", "POD", "CONTAINER", "REASON", "EXIT CODE", "RESTARTS", "CLUSTER", "FLAGS"}}
+	for _, cluster := range nearby.ClusterTerminations(result.Terminations, *window) {
+		clusterName := "-"
+		if spansPods(cluster) {
+			clusters++
+			clusterName = strconv.Itoa(clusters)
+		}
+		for _, termination := range cluster {
+			flags := []string{}
+			if termination.Namespace == namespace && termination.Pod == podName {
+				flags = append(flags, "TARGET")
+			}
+			if termination.OOMKilled() {
+				flags = append(flags, "OOMKILLED")
+				oomKilled++
+			}
+			rows = append(rows, []string{
+				termination.FinishedAt.UTC().Format(time.RFC3339),
+				termination.Namespace,
+				termination.Pod,
+				termination.Container,
+				output.DashIfEmpty(termination.Reason),
+				strconv.Itoa(int(termination.ExitCode)),
+				strconv.Itoa(int(termination.RestartCount)),
+				clusterName,
+				output.DashIfEmpty(strings.Join(flags, ",")),
+			})
+		}
+	}
+
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	summary := fmt.Sprintf("%v: %v terminations, %v clusters of restarts within %v, %v OOMKilled", header, len(result.Terminations), clusters, shortDuration(*window), oomKilled)
+	_, err = fmt.Fprintln(writer, summary+"\n\n"+formatted)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	return nil
+}
+
+// shortDuration formats the duration like time.Duration.String without
+// trailing zero units, e.g. 10m instead of 10m0s and 1h instead of 1h0m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// spansPods returns true if the terminations are of more than one pod.
+func spansPods(terminations []nearby.Termination) bool {
+	for _, termination := range terminations {
+		if termination.Namespace != terminations[0].Namespace || termination.Pod != terminations[0].Pod {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package restarts_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/restarts"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	t.Run("with a pod, shows the restarts on its node as a timeline", func(t *testing.T) {
+		expected := `Restarts near pod testing-cluster-default/api-1 on node node-a-1: 4 terminations, 1 clusters of restarts within 10m, 2 OOMKilled
+
+LAST TERMINATED       NAMESPACE                POD      CONTAINER  REASON     EXIT CODE  RESTARTS  CLUSTER  FLAGS
+2024-01-01T09:00:00Z  testing-cluster-default  api-1    main       Error      1          3         -        TARGET
+2024-01-01T11:50:00Z  testing-cluster-default  batch-1  main       OOMKilled  137        1         1        OOMKILLED
+2024-01-01T11:55:00Z  testing-cluster-default  api-1    sidecar    Completed  0          1         1        TARGET
+2024-01-01T11:58:00Z  testing-cluster-default  cache-1  main       OOMKilled  137        5         1        OOMKILLED
+`
+		got, err := execute([]string{"api-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with a shorter window, splits the cluster", func(t *testing.T) {
+		expected := `Restarts near pod testing-cluster-default/api-1 on node node-a-1: 4 terminations, 1 clusters of restarts within 4m, 2 OOMKilled
+
+LAST TERMINATED       NAMESPACE                POD      CONTAINER  REASON     EXIT CODE  RESTARTS  CLUSTER  FLAGS
+2024-01-01T09:00:00Z  testing-cluster-default  api-1    main       Error      1          3         -        TARGET
+2024-01-01T11:50:00Z  testing-cluster-default  batch-1  main       OOMKilled  137        1         -        OOMKILLED
+2024-01-01T11:55:00Z  testing-cluster-default  api-1    sidecar    Completed  0          1         1        TARGET
+2024-01-01T11:58:00Z  testing-cluster-default  cache-1  main       OOMKilled  137        5         1        OOMKILLED
+`
+		got, err := execute([]string{"api-1", "--window", "4m"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	for _, testCase := range []struct{ window, expected string }{
+		{"30s", "0 clusters of restarts within 30s"},
+		{"10s", "0 clusters of restarts within 10s"},
+		{"1h", "1 clusters of restarts within 1h,"},
+		{"1h30m", "1 clusters of restarts within 1h30m,"},
+	} {
+		t.Run("with a window of "+testCase.window+", shows it in the summary", func(t *testing.T) {
+			got, err := execute([]string{"api-1", "--window", testCase.window})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(got, testCase.expected) {
+				t.Errorf("Expected output to contain: %v\ngot:\n%v", testCase.expected, got)
+			}
+		})
+	}
+
+	t.Run("with a node without restarts, says so", func(t *testing.T) {
+		expected := "Restarts near pod testing-cluster-default/web-1 on node node-b-1: no containers have restarted\n"
+		got, err := execute([]string{"web-1"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an unknown pod, returns not found", func(t *testing.T) {
+		_, err := execute([]string{"missing"})
+		if errs.ExitCode(err) != errs.ExitNotFound {
+			t.Errorf("Expected a not found error, got: %v", err)
+		}
+	})
+
+	t.Run("with an unscheduled pod, returns an unscheduled error", func(t *testing.T) {
+		_, err := execute([]string{"pending-1"})
+		if errs.ExitCode(err) != errs.ExitUnscheduled {
+			t.Errorf("Expected an unscheduled error, got: %v", err)
+		}
+	})
+
+	t.Run("with no pod, returns a usage error", func(t *testing.T) {
+		_, err := execute([]string{"--window", "5m"})
+		if errs.ExitCode(err) != errs.ExitUsage {
+			t.Errorf("Expected a usage error, got: %v", err)
+		}
+	})
+}
+
+func execute(args []string) (string, error) {
+	return clienttest.Execute(&restarts.RestartsCLI{Client: testClient()}, args)
+}
+
+// testClient returns a fake client where the target api-1 on node-a-1
+// restarted at 09:00 and had its sidecar restart at 11:55, between the
+// OOMKills of its neighbors batch-1 (11:50) and cache-1 (11:58). web-1 on
+// node-b-1 has never restarted, while worker-1 on node-c-1 has. pending-1 is
+// unscheduled.
+func testClient() kubernetes.Interface {
+	api := testPod("api-1", "node-a-1",
+		terminated("main", 3, "Error", 1, "2024-01-01T09:00:00Z"),
+		terminated("sidecar", 1, "Completed", 0, "2024-01-01T11:55:00Z"),
+	)
+	return testclient.NewSimpleClientset(
+		api,
+		testPod("batch-1", "node-a-1", terminated("main", 1, "OOMKilled", 137, "2024-01-01T11:50:00Z")),
+		testPod("cache-1", "node-a-1", terminated("main", 5, "OOMKilled", 137, "2024-01-01T11:58:00Z")),
+		testPod("nginx-1", "node-a-1", v1.ContainerStatus{Name: "main"}),
+		testPod("web-1", "node-b-1", v1.ContainerStatus{Name: "main"}),
+		testPod("worker-1", "node-c-1", terminated("main", 1, "OOMKilled", 137, "2024-01-01T11:52:00Z")),
+		testPod("pending-1", ""),
+	)
+}
+
+// testPod returns a pod from clienttest.Pod with the given container statuses.
+func testPod(name string, nodeName string, statuses ...v1.ContainerStatus) *v1.Pod {
+	pod := clienttest.Pod(name, nodeName, nil)
+	pod.Status.ContainerStatuses = statuses
+	return pod
+}
+
+// terminated returns the status of a restarted container whose last
+// termination finished at the given RFC 3339 time.
+func terminated(name string, restarts int32, reason string, exitCode int32, finishedAt string) v1.ContainerStatus {
+	finished, err := time.Parse(time.RFC3339, finishedAt)
+	if err != nil {
+		panic(err)
+	}
+	return v1.ContainerStatus{
+		Name:         name,
+		RestartCount: restarts,
+		LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+			Reason:     reason,
+			ExitCode:   exitCode,
+			FinishedAt: metav1.NewTime(finished),
+		}},
+	}
+}
@@ -0,0 +1,216 @@
+// Package spread provides a CLI to audit how the pods of a workload are
+// spread across nodes, zones and regions.
+package spread
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/leejones/kubectl-nearby/pkg/cli"
+	"github.com/leejones/kubectl-nearby/pkg/client"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/nearby"
+	"github.com/leejones/kubectl-nearby/pkg/output"
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+// A SpreadCLI is used to create a command line interface for auditing the
+// spread of a workload's pods.
+type SpreadCLI struct {
+	Client kubernetes.Interface
+}
+
+// ErrTargetRequired is returned when neither a workload nor a selector is
+// given.
+type ErrTargetRequired struct{}
+
+func (err ErrTargetRequired) Error() string {
+	return "a workload (TYPE/NAME) or selector is required"
+}
+
+func (err ErrTargetRequired) ExitCode() int {
+	return errs.ExitUsage
+}
+
+// levels are the topology levels the spread is shown for.
+var levels = []string{topology.LevelNode, topology.LevelZone, topology.LevelRegion}
+
+// A limit is the maximum number of pods allowed in a domain, either as a
+// count or a percentage of all pods.
+type limit struct {
+	value   float64
+	percent bool
+}
+
+// parseLimit parses a count (e.g. 2) or a percentage (e.g. 50%). It returns
+// nil for an empty value.
+func parseLimit(value string) (*limit, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, percent := strings.CutSuffix(value, "%")
+	parsed, err := strconv.ParseFloat(number, 64)
+	if err != nil || parsed < 0 || (percent && parsed > 100) {
+		return nil, fmt.Errorf("invalid maximum: %q (must be a count, e.g. 2, or a percentage, e.g. 50%%)", value)
+	}
+	return &limit{value: parsed, percent: percent}, nil
+}
+
+func (l limit) String() string {
+	if l.percent {
+		return strconv.FormatFloat(l.value, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(l.value, 'f', -1, 64)
+}
+
+// exceeded returns true if count of total pods is more than the limit.
+func (l limit) exceeded(count int, total int) bool {
+	if l.percent {
+		return float64(count)*100 > l.value*float64(total)
+	}
+	return float64(count) > l.value
+}
+
+// Execute writes how the target's pods are spread across nodes, zones and
+// regions to the given io.Writer and returns an error. An
+// errs.ErrSpreadViolation is returned if a maximum is exceeded.
+func (s *SpreadCLI) Execute(args []string, writer io.Writer) error {
+	return s.ExecuteContext(context.Background(), args, writer)
+}
+
+// ExecuteContext is like Execute but stops any requests to the cluster when
+// the context is done.
+func (s *SpreadCLI) ExecuteContext(ctx context.Context, args []string, writer io.Writer) error {
+	var kind, name string
+	remainingArgs := args
+	if len(args) > 0 {
+		matched, err := regexp.MatchString("^-", args[0])
+		if err != nil {
+			return fmt.Errorf("Error parsing arguments")
+		}
+		if !matched {
+			kind, name, err = nearby.ParseTarget(args[0])
+			if err != nil {
+				return err
+			}
+			if kind == nearby.KindPod {
+				return errs.ErrUsage{Err: fmt.Errorf("the target must be a workload (TYPE/NAME), not a pod: %v", args[0])}
+			}
+			remainingArgs = args[1:]
+		}
+	}
+
+	f := flag.NewFlagSet("kubectl nearby spread", flag.ContinueOnError)
+	f.Usage = func() {
+		fmt.Fprintf(f.Output(), "Show how a workload's pods are spread across nodes, zones and regions.\n\nUSAGE\n\n  %s spread TYPE/NAME [OPTIONS]\n  %s spread -l SELECTOR [OPTIONS]\n\nTYPE is one of: deploy, sts, ds, job, rs.\n\nOPTIONS\n\n", os.Args[0], os.Args[0])
+		f.PrintDefaults()
+	}
+	f.SetOutput(ioutil.Discard)
+
+	var connection client.Flags
+	connection.AddFlags(f)
+	var selector string
+	f.StringVar(&selector, "selector", "", "Label selector for the pods (e.g. app=checkout), used instead of a workload")
+	f.StringVar(&selector, "l", "", "Shorthand for --selector")
+	maximums := map[string]*string{}
+	for _, level := range levels {
+		maximums[level] = f.String("max-per-"+level, "", fmt.Sprintf("The most pods allowed in a single %v, as a count (e.g. 2) or a percentage of the pods (e.g. 50%%). Exceeding it exits with code %v", level, errs.ExitSpreadViolation))
+	}
+
+	err := f.Parse(remainingArgs)
+	if err == flag.ErrHelp {
+		cli.Usage(f, writer)
+		return nil
+	} else if err != nil {
+		return errs.ErrUsage{Err: err}
+	}
+
+	if name == "" && selector == "" {
+		return ErrTargetRequired{}
+	} else if name != "" && selector != "" {
+		return errs.ErrUsage{Err: fmt.Errorf("a workload and a selector cannot be given together")}
+	}
+
+	limits := map[string]*limit{}
+	for _, level := range levels {
+		limits[level], err = parseLimit(*maximums[level])
+		if err != nil {
+			return errs.ErrUsage{Err: fmt.Errorf("--max-per-%v: %v", level, err)}
+		}
+	}
+
+	namespace, err := connection.CurrentNamespace()
+	if err != nil {
+		return err
+	}
+	if s.Client == nil {
+		s.Client, err = connection.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	var pods []v1.Pod
+	if selector != "" {
+		pods, err = nearby.SelectorPods(ctx, s.Client, namespace, selector)
+	} else {
+		pods, err = nearby.WorkloadPods(ctx, s.Client, namespace, kind, name)
+	}
+	if err != nil {
+		return err
+	}
+	locations, err := nearby.Locate(ctx, s.Client, pods)
+	if err != nil {
+		return err
+	}
+
+	total := len(locations)
+	violations := []string{}
+	rows := [][]string{{"LEVEL", "DOMAIN", "PODS", "SHARE", "STATUS"}}
+	for _, level := range levels {
+		for _, domain := range nearby.Spread(locations, level) {
+			count := len(domain.Pods)
+			status := "OK"
+			switch {
+			case domain.Name == "":
+				status = "-"
+			case limits[level] != nil && limits[level].exceeded(count, total):
+				status = fmt.Sprintf("OVER MAX (%v)", limits[level])
+				violations = append(violations, fmt.Sprintf("%v %v has %v of %v pods, more than the maximum of %v", level, domain.Name, count, total, limits[level]))
+			case level != topology.LevelRegion && total > 1 && count == total:
+				// Regions usually hold every pod, nodes and zones shouldn't.
+				status = "CONCENTRATED"
+			}
+			rows = append(rows, []string{
+				level,
+				output.UnknownIfEmpty(domain.Name),
+				strconv.Itoa(count),
+				fmt.Sprintf("%.0f%%", float64(count)*100/float64(total)),
+				status,
+			})
+		}
+	}
+	formatted, err := output.Columns(rows)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+	_, err = fmt.Fprintln(writer, formatted)
+	if err != nil {
+		return fmt.Errorf("printing output: %v", err)
+	}
+
+	if len(violations) > 0 {
+		return errs.ErrSpreadViolation{Violations: violations}
+	}
+	return nil
+}
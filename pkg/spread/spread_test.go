@@ -0,0 +1,118 @@
+package spread_test
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/leejones/kubectl-nearby/pkg/client/clienttest"
+	"github.com/leejones/kubectl-nearby/pkg/errs"
+	"github.com/leejones/kubectl-nearby/pkg/spread"
+)
+
+func TestExecute(t *testing.T) {
+	clienttest.SetupKubeconfig(t)
+
+	t.Run("with a workload, shows the spread of its pods", func(t *testing.T) {
+		expected := `LEVEL   DOMAIN      PODS  SHARE  STATUS
+node    node-a-1    2     67%    OK
+node    node-b-1    1     33%    OK
+zone    us-east4-a  2     67%    OK
+zone    us-east4-b  1     33%    OK
+region  us-east4    3     100%   OK
+`
+		got, err := execute([]string{"sts/redis"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with pods on one node, flags the concentration", func(t *testing.T) {
+		expected := `LEVEL   DOMAIN      PODS  SHARE  STATUS
+node    node-a-1    2     100%   CONCENTRATED
+zone    us-east4-a  2     100%   CONCENTRATED
+region  us-east4    2     100%   OK
+`
+		got, err := execute([]string{"-l", "app=web"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	t.Run("with an exceeded maximum, returns a spread violation", func(t *testing.T) {
+		expected := `LEVEL   DOMAIN      PODS  SHARE  STATUS
+node    node-a-1    2     67%    OVER MAX (1)
+node    node-b-1    1     33%    OK
+zone    us-east4-a  2     67%    OVER MAX (50%)
+zone    us-east4-b  1     33%    OK
+region  us-east4    3     100%   OK
+`
+		got, err := execute([]string{"sts/redis", "--max-per-node", "1", "--max-per-zone", "50%", "--max-per-region", "100%"})
+		var violation errs.ErrSpreadViolation
+		if !errors.As(err, &violation) {
+			t.Fatalf("Expected error type: %T, got: %T (%v)", violation, err, err)
+		}
+		if len(violation.Violations) != 2 {
+			t.Errorf("Expected 2 violations, got: %v", violation.Violations)
+		}
+		if errs.ExitCode(err) != errs.ExitSpreadViolation {
+			t.Errorf("Expected exit code: %v, got: %v", errs.ExitSpreadViolation, errs.ExitCode(err))
+		}
+		if expected != got {
+			t.Errorf("Expected output:\n%v\ngot:\n%v", expected, got)
+		}
+	})
+
+	var usageCases = []struct {
+		name string
+		args []string
+	}{
+		{"with no target", []string{}},
+		{"with a pod", []string{"redis-0"}},
+		{"with a workload and a selector", []string{"sts/redis", "-l", "app=web"}},
+		{"with an invalid maximum", []string{"sts/redis", "--max-per-zone", "half"}},
+		{"with a percentage over 100", []string{"sts/redis", "--max-per-zone", "150%"}},
+	}
+	for _, testCase := range usageCases {
+		t.Run(testCase.name+", returns a usage error", func(t *testing.T) {
+			_, err := execute(testCase.args)
+			if errs.ExitCode(err) != errs.ExitUsage {
+				t.Errorf("Expected a usage error, got: %v", err)
+			}
+		})
+	}
+}
+
+func execute(args []string) (string, error) {
+	return clienttest.Execute(&spread.SpreadCLI{Client: testClient()}, args)
+}
+
+// testClient returns a fake client with the StatefulSet redis spread over
+// two zones and the pods labeled app=web on a single node.
+func testClient() kubernetes.Interface {
+	return testclient.NewSimpleClientset(
+		clienttest.Node("node-a-1", clienttest.TopologyLabels("us-east4-a", "us-east4")),
+		clienttest.Node("node-b-1", clienttest.TopologyLabels("us-east4-b", "us-east4")),
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis", Namespace: clienttest.Namespace, UID: "redis"},
+			Spec: appsv1.StatefulSetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "redis"}},
+			},
+		},
+		clienttest.OwnedPod("redis-0", "node-a-1", "StatefulSet", "redis"),
+		clienttest.OwnedPod("redis-1", "node-a-1", "StatefulSet", "redis"),
+		clienttest.OwnedPod("redis-2", "node-b-1", "StatefulSet", "redis"),
+		clienttest.Pod("web-1", "node-a-1", map[string]string{"app": "web"}),
+		clienttest.Pod("web-2", "node-a-1", map[string]string{"app": "web"}),
+	)
+}
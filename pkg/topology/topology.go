@@ -0,0 +1,93 @@
+// Package topology resolves the node labels used to decide whether nodes are
+// nearby each other.
+package topology
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Well-known topology levels.
+const (
+	LevelNode   = "node"
+	LevelZone   = "zone"
+	LevelRegion = "region"
+)
+
+// Well-known topology labels set on nodes.
+const (
+	LabelHostname = "kubernetes.io/hostname"
+	LabelZone     = "topology.kubernetes.io/zone"
+	LabelRegion   = "topology.kubernetes.io/region"
+
+	// Older clusters only set the beta failure-domain labels.
+	LabelZoneDeprecated   = "failure-domain.beta.kubernetes.io/zone"
+	LabelRegionDeprecated = "failure-domain.beta.kubernetes.io/region"
+)
+
+// Keys returns the node label keys for the given topology level in the order
+// they should be tried. Levels other than the well-known ones are treated as
+// a label key (e.g. a custom rack label).
+func Keys(level string) ([]string, error) {
+	switch level {
+	case "":
+		return nil, fmt.Errorf("a topology level is required")
+	case LevelNode:
+		return []string{LabelHostname}, nil
+	case LevelZone:
+		return []string{LabelZone, LabelZoneDeprecated}, nil
+	case LevelRegion:
+		return []string{LabelRegion, LabelRegionDeprecated}, nil
+	}
+	return []string{level}, nil
+}
+
+// WithFallbacks returns the keys with the deprecated label of each
+// well-known key (e.g. failure-domain.beta.kubernetes.io/zone for
+// topology.kubernetes.io/zone) tried right after it, like Keys does for the
+// well-known levels. Deprecated labels already in keys are not repeated.
+func WithFallbacks(keys []string) []string {
+	fallbacks := map[string]string{
+		LabelZone:   LabelZoneDeprecated,
+		LabelRegion: LabelRegionDeprecated,
+	}
+	given := map[string]bool{}
+	for _, key := range keys {
+		given[key] = true
+	}
+	result := []string{}
+	for _, key := range keys {
+		result = append(result, key)
+		if fallback, ok := fallbacks[key]; ok && !given[fallback] {
+			result = append(result, fallback)
+		}
+	}
+	return result
+}
+
+// Value returns the value of the first of the given keys found in labels, the
+// key it was found under, and whether any key was found.
+func Value(labels map[string]string, keys []string) (value string, key string, ok bool) {
+	for _, key := range keys {
+		value, ok := labels[key]
+		if ok {
+			return value, key, true
+		}
+	}
+	return "", "", false
+}
+
+// ColumnName returns a column header for values of the given label key (e.g.
+// ZONE for topology.kubernetes.io/zone or RACK for example.com/rack).
+func ColumnName(key string) string {
+	switch key {
+	case LabelZone, LabelZoneDeprecated:
+		return "ZONE"
+	case LabelRegion, LabelRegionDeprecated:
+		return "REGION"
+	case LabelHostname:
+		return "NODE"
+	}
+	parts := strings.Split(key, "/")
+	return strings.ToUpper(parts[len(parts)-1])
+}
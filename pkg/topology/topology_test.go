@@ -0,0 +1,112 @@
+package topology_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/leejones/kubectl-nearby/pkg/topology"
+)
+
+func TestKeys(t *testing.T) {
+	var testCases = []struct {
+		level string
+		keys  []string
+	}{
+		{"node", []string{"kubernetes.io/hostname"}},
+		{"zone", []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}},
+		{"region", []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}},
+		{"example.com/rack", []string{"example.com/rack"}},
+	}
+	for _, testCase := range testCases {
+		got, err := topology.Keys(testCase.level)
+		if err != nil {
+			t.Errorf("Unexpected error for level %v: %v", testCase.level, err)
+		}
+		if !reflect.DeepEqual(testCase.keys, got) {
+			t.Errorf("Expected Keys(%v) to return: %v, got: %v", testCase.level, testCase.keys, got)
+		}
+	}
+
+	_, err := topology.Keys("")
+	if err == nil {
+		t.Errorf("Expected an error for an empty level")
+	}
+}
+
+func TestWithFallbacks(t *testing.T) {
+	var testCases = []struct {
+		name     string
+		keys     []string
+		expected []string
+	}{
+		{"with a custom key", []string{"example.com/rack"}, []string{"example.com/rack"}},
+		{
+			"with well-known keys, adds their deprecated labels",
+			[]string{"topology.kubernetes.io/zone", "example.com/rack", "topology.kubernetes.io/region"},
+			[]string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone", "example.com/rack", "topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"},
+		},
+		{
+			"with a deprecated label given, keeps its order",
+			[]string{"failure-domain.beta.kubernetes.io/zone", "topology.kubernetes.io/zone"},
+			[]string{"failure-domain.beta.kubernetes.io/zone", "topology.kubernetes.io/zone"},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got := topology.WithFallbacks(testCase.keys)
+			if !reflect.DeepEqual(testCase.expected, got) {
+				t.Errorf("Expected keys: %v, got: %v", testCase.expected, got)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	keys := []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+
+	t.Run("prefers the first key", func(t *testing.T) {
+		labels := map[string]string{
+			"topology.kubernetes.io/zone":            "us-east4-a",
+			"failure-domain.beta.kubernetes.io/zone": "legacy-a",
+		}
+		value, key, ok := topology.Value(labels, keys)
+		if !ok || value != "us-east4-a" || key != "topology.kubernetes.io/zone" {
+			t.Errorf("Expected us-east4-a from topology.kubernetes.io/zone, got: %v from %v (%v)", value, key, ok)
+		}
+	})
+
+	t.Run("falls back to later keys", func(t *testing.T) {
+		labels := map[string]string{
+			"failure-domain.beta.kubernetes.io/zone": "legacy-a",
+		}
+		value, key, ok := topology.Value(labels, keys)
+		if !ok || value != "legacy-a" || key != "failure-domain.beta.kubernetes.io/zone" {
+			t.Errorf("Expected legacy-a from failure-domain.beta.kubernetes.io/zone, got: %v from %v (%v)", value, key, ok)
+		}
+	})
+
+	t.Run("reports missing keys", func(t *testing.T) {
+		_, _, ok := topology.Value(map[string]string{}, keys)
+		if ok {
+			t.Errorf("Expected no value to be found")
+		}
+	})
+}
+
+func TestColumnName(t *testing.T) {
+	var testCases = []struct {
+		key  string
+		name string
+	}{
+		{"topology.kubernetes.io/zone", "ZONE"},
+		{"failure-domain.beta.kubernetes.io/region", "REGION"},
+		{"example.com/rack", "RACK"},
+		{"row", "ROW"},
+	}
+	for _, testCase := range testCases {
+		got := topology.ColumnName(testCase.key)
+		if testCase.name != got {
+			t.Errorf("Expected ColumnName(%v) to return: %v, got: %v", testCase.key, testCase.name, got)
+		}
+	}
+}